@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/service"
+	"github.com/rryowa/medods_dvortsov/internal/storage/postgres"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+// issuedAPIKey вывод api-key create и api-key rotate: ключ показывается один раз
+type issuedAPIKey struct {
+	Key string `json:"key"`
+	*models.APIKey
+}
+
+func newAPIKeyCmd(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "api-key",
+		Short: "Manage named API keys stored in Postgres",
+	}
+
+	var (
+		name      string
+		scopes    []string
+		expiresIn time.Duration
+	)
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Issue a named API key and print it once",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return withAPIKeyService(cmd.Context(), opts, func(aks *service.APIKeyService) error {
+				key, created, err := aks.CreateKey(cmd.Context(), name, scopes, expiresAt(expiresIn))
+				if err != nil {
+					return err
+				}
+				return printIssuedAPIKey(cmd.OutOrStdout(), key, created)
+			})
+		},
+	}
+	createCmd.Flags().StringVar(&name, "name", "", "key name, shown in request logs and the audit actor")
+	createCmd.Flags().StringSliceVar(&scopes, "scope", nil,
+		"scope granted to the key, repeatable ("+strings.Join(models.APIKeyScopes(), ", ")+")")
+	createCmd.Flags().DurationVar(&expiresIn, "expires-in", 0, "key lifetime, 0 - the key does not expire")
+	_ = createCmd.MarkFlagRequired("name")
+	_ = createCmd.MarkFlagRequired("scope")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List API keys with their metadata",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return withAPIKeyService(cmd.Context(), opts, func(aks *service.APIKeyService) error {
+				keys, err := aks.ListKeys(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return printAPIKeys(cmd.OutOrStdout(), keys, time.Now().UTC())
+			})
+		},
+	}
+
+	var (
+		overlap          time.Duration
+		rotatedExpiresIn time.Duration
+	)
+	rotateCmd := &cobra.Command{
+		Use:   "rotate ID",
+		Short: "Issue a replacement key; the old key keeps working for the overlap period",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseAPIKeyID(args[0])
+			if err != nil {
+				return err
+			}
+			// Без --overlap действует API_KEY_ROTATION_OVERLAP
+			var gracePeriod *time.Duration
+			if cmd.Flags().Changed("overlap") {
+				gracePeriod = &overlap
+			}
+			return withAPIKeyService(cmd.Context(), opts, func(aks *service.APIKeyService) error {
+				key, created, err := aks.RotateKey(cmd.Context(), id, gracePeriod, expiresAt(rotatedExpiresIn))
+				if err != nil {
+					return err
+				}
+				return printIssuedAPIKey(cmd.OutOrStdout(), key, created)
+			})
+		},
+	}
+	rotateCmd.Flags().DurationVar(&overlap, "overlap", 0,
+		"how long the old key is still accepted (default api_key.rotation_overlap)")
+	rotateCmd.Flags().DurationVar(&rotatedExpiresIn, "expires-in", 0, "new key lifetime, 0 - the key does not expire")
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke ID",
+		Short: "Revoke an API key immediately",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseAPIKeyID(args[0])
+			if err != nil {
+				return err
+			}
+			return withAPIKeyService(cmd.Context(), opts, func(aks *service.APIKeyService) error {
+				if err := aks.RevokeKey(cmd.Context(), id); err != nil {
+					return err
+				}
+				_, err := fmt.Fprintf(cmd.OutOrStdout(), "api key %d revoked\n", id)
+				return err
+			})
+		},
+	}
+
+	cmd.AddCommand(createCmd, listCmd, rotateCmd, revokeCmd)
+	return cmd
+}
+
+// withAPIKeyService открывает соединение с БД на время одной команды.
+// Redis не нужен: общий ключ из AUTH_SERVICE_API_KEY командами не управляется
+func withAPIKeyService(ctx context.Context, opts *rootOptions, fn func(aks *service.APIKeyService) error) error {
+	cfg, err := util.LoadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+	// stdout занят выводом команды, ошибки возвращаются
+	logger := zap.NewNop().Sugar()
+
+	db, dbCleanup, err := util.NewDBConnection(ctx, logger, &cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer dbCleanup()
+
+	return fn(service.NewAPIKeyService(nil, postgres.NewStorage(db), &cfg.APIKey, logger))
+}
+
+func parseAPIKeyID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid api key id %q", arg)
+	}
+	return id, nil
+}
+
+func expiresAt(lifetime time.Duration) *time.Time {
+	if lifetime <= 0 {
+		return nil
+	}
+	t := time.Now().UTC().Add(lifetime)
+	return &t
+}
+
+func printIssuedAPIKey(out io.Writer, key string, created *models.APIKey) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(issuedAPIKey{Key: key, APIKey: created}); err != nil {
+		return fmt.Errorf("encode api key: %w", err)
+	}
+	return nil
+}
+
+func printAPIKeys(out io.Writer, keys []models.APIKey, now time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tSTATUS\tEXPIRES\tLAST USED")
+	for _, k := range keys {
+		status := "active"
+		switch {
+		case k.RevokedAt != nil:
+			status = "revoked"
+		case !k.Usable(now):
+			status = "expired"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			k.ID, k.Name, k.Prefix, strings.Join(k.Scopes, ","), status,
+			formatOptionalTime(k.ExpiresAt), formatOptionalTime(k.LastUsedAt))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write api keys: %w", err)
+	}
+	return nil
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
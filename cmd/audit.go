@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/service"
+	"github.com/rryowa/medods_dvortsov/internal/storage/postgres"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+func newAuditCmd(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit log",
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the audit log hash chain and its head",
+		Long: "Recompute the hash of every linked audit event, check the links between events and compare the last\n" +
+			"event with audit_chain_head. Exits with an error if an event was modified, removed or the tail\n" +
+			"of the chain was truncated. Events the service has not linked yet are not checked.\n" +
+			"Prints the head hash: keep it outside the database to detect a rollback of the whole log.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return withAuditService(cmd.Context(), opts, func(as *service.AuditService) error {
+				checked, head, err := as.VerifyChain(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("%d events verified before the failure: %w", checked, err)
+				}
+				_, err = fmt.Fprintf(cmd.OutOrStdout(), "audit chain ok: %d events, head event %d, hash %s\n",
+					checked, head.EventID, hex.EncodeToString(head.Hash))
+				return err
+			})
+		},
+	}
+
+	cmd.AddCommand(verifyCmd)
+	return cmd
+}
+
+// withAuditService открывает соединение с БД на время одной команды
+func withAuditService(ctx context.Context, opts *rootOptions, fn func(as *service.AuditService) error) error {
+	cfg, err := util.LoadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+	// stdout занят выводом команды, ошибки возвращаются
+	logger := zap.NewNop().Sugar()
+
+	db, dbCleanup, err := util.NewDBConnection(ctx, logger, &cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer dbCleanup()
+
+	return fn(service.NewAuditService(postgres.NewStorage(db), logger))
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+func newConfigCmd(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect service configuration",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "print",
+		Short: "Print effective config (defaults, file, environment) with secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := util.LoadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			return cfg.DumpRedacted(cmd.OutOrStdout())
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rryowa/medods_dvortsov/internal/service"
+)
+
+const (
+	// apiKeyLength байт случайности в API-ключе, как в прежнем make keygen
+	apiKeyLength = 32
+	// kekLength размер ключа AES-256 для шифрования ключей подписи в БД
+	kekLength = 32
+)
+
+type keygenOptions struct {
+	out   string
+	force bool
+}
+
+func newKeygenCmd() *cobra.Command {
+	opts := &keygenOptions{}
+	cmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate JWT signing keys and API keys",
+	}
+	cmd.PersistentFlags().StringVarP(&opts.out, "out", "o", "", "write the key to a file (mode 0600) instead of stdout")
+	cmd.PersistentFlags().BoolVar(&opts.force, "force", false, "overwrite the output file if it exists")
+
+	var alg string
+	jwtCmd := &cobra.Command{
+		Use:   "jwt",
+		Short: "Generate a JWT signing key: PEM private key or base64 HS512 secret",
+		Long: "Generate a JWT signing key. For RS256, ES256 and EdDSA the output is a PKCS#8 PEM private key\n" +
+			"(JWT_PRIVATE_KEY / JWT_PRIVATE_KEY_FILE), for HS512 a base64 secret (JWT_SECRET).",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			material, err := service.GenerateKeyMaterial(alg)
+			if err != nil {
+				return err
+			}
+			if alg == service.AlgHS512 {
+				material = []byte(base64.StdEncoding.EncodeToString(material) + "\n")
+			}
+			return writeKey(cmd.OutOrStdout(), opts, material)
+		},
+	}
+	jwtCmd.Flags().StringVar(&alg, "alg", service.AlgES256, "signing algorithm: HS512, RS256, ES256 or EdDSA")
+
+	apiKeyCmd := &cobra.Command{
+		Use:   "api-key",
+		Short: "Generate a random API key (AUTH_SERVICE_API_KEY)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			key := make([]byte, apiKeyLength)
+			if _, err := rand.Read(key); err != nil {
+				return fmt.Errorf("failed to read random bytes: %w", err)
+			}
+			return writeKey(cmd.OutOrStdout(), opts, []byte(base64.StdEncoding.EncodeToString(key)+"\n"))
+		},
+	}
+
+	kekCmd := &cobra.Command{
+		Use:   "kek",
+		Short: "Generate a key that encrypts stored signing keys (JWT_KEY_ENCRYPTION_KEY)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			key := make([]byte, kekLength)
+			if _, err := rand.Read(key); err != nil {
+				return fmt.Errorf("failed to read random bytes: %w", err)
+			}
+			return writeKey(cmd.OutOrStdout(), opts, []byte(base64.StdEncoding.EncodeToString(key)+"\n"))
+		},
+	}
+
+	cmd.AddCommand(jwtCmd, apiKeyCmd, kekCmd)
+	return cmd
+}
+
+// writeKey пишет ключ в stdout или в файл, доступный только владельцу.
+// Существующий файл без --force не перезаписывается: так легко потерять действующий ключ.
+func writeKey(stdout io.Writer, opts *keygenOptions, key []byte) error {
+	if opts.out == "" {
+		if _, err := stdout.Write(key); err != nil {
+			return fmt.Errorf("write key: %w", err)
+		}
+		return nil
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if opts.force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(opts.out, flags, 0o600)
+	if err != nil {
+		return fmt.Errorf("open key file: %w", err)
+	}
+	if _, err := f.Write(key); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write key file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close key file: %w", err)
+	}
+	return nil
+}
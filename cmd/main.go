@@ -1,62 +1,44 @@
 package main
 
 import (
-	"context"
+	"os"
 
-	"go.uber.org/zap"
+	"github.com/spf13/cobra"
 
-	"github.com/rryowa/medods_dvortsov/internal/api"
-	"github.com/rryowa/medods_dvortsov/internal/controller"
-	"github.com/rryowa/medods_dvortsov/internal/migrations"
-	"github.com/rryowa/medods_dvortsov/internal/service"
-	"github.com/rryowa/medods_dvortsov/internal/storage/postgres"
-	"github.com/rryowa/medods_dvortsov/internal/storage/redis"
 	"github.com/rryowa/medods_dvortsov/internal/util"
-
-	_ "github.com/lib/pq"
 )
 
-func main() {
-	ctx := context.Background()
-	logger := util.NewZapLogger()
+// rootOptions общие флаги всех команд
+type rootOptions struct {
+	configPath string
+}
 
-	db, dbCleanup, err := util.NewDBConnection(ctx, logger)
-	if err != nil {
-		logger.Fatal(zap.Error(err))
-	}
-	if err := migrations.RunMigrations(db, logger, "./internal/migrations"); err != nil {
-		logger.Fatal(zap.Error(err))
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
 	}
+}
 
-	redisClient, redisCleanup, err := util.NewRedisClient(logger, util.NewRedisConfig())
-	if err != nil {
-		logger.Fatal(zap.Error(err))
-	}
+func newRootCmd() *cobra.Command {
+	opts := &rootOptions{}
 
-	apiKeyService := service.NewAPIKeyService(redisClient, logger)
-	if err := apiKeyService.SyncAPIKey(ctx); err != nil {
-		logger.Fatal(zap.Error(err))
+	root := &cobra.Command{
+		Use:   "auth-service",
+		Short: "Authentication service: access/refresh tokens, sessions, webhooks",
+		// Ошибку печатает cobra, справка по флагам при ошибке выполнения только мешает
+		SilenceUsage: true,
 	}
-
-	storage := postgres.NewStorage(db)
-	cleanupFuncs := []func(){dbCleanup, redisCleanup}
-
-	tokenStorage := redis.NewTokenStorage(redisClient)
-	tokenService := service.NewTokenService(util.NewTokenConfig(), tokenStorage)
-	webhookService := service.NewWebhookService(logger, util.GetWebhookURL())
-	authService := service.NewAuthService(tokenService, storage, webhookService, logger)
-
-	controller := controller.NewController(authService, logger)
-
-	apiServer := api.NewAPI(
-		controller,
-		authService,
-		apiKeyService,
-		redisClient,
-		util.NewServerConfig(),
-		logger,
-		cleanupFuncs,
+	root.PersistentFlags().StringVar(&opts.configPath, "config", "",
+		"path to YAML config file (default $"+util.ConfigFileEnv+")")
+
+	root.AddCommand(
+		newServeCmd(opts),
+		newMigrateCmd(opts),
+		newKeygenCmd(),
+		newTokenCmd(opts),
+		newConfigCmd(opts),
+		newAPIKeyCmd(opts),
+		newAuditCmd(opts),
 	)
-
-	apiServer.Run(ctx)
+	return root
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rryowa/medods_dvortsov/internal/migrations"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+func newMigrateCmd(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage database schema migrations (embedded in the binary)",
+	}
+
+	for _, sub := range []struct{ use, short string }{
+		{"up", "Apply all pending migrations"},
+		{"down", "Roll back the latest migration"},
+		{"status", "Show applied and pending migrations"},
+		{"redo", "Roll back the latest migration and apply it again"},
+	} {
+		cmd.AddCommand(&cobra.Command{
+			Use:   sub.use,
+			Short: sub.short,
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return runMigrate(cmd.Context(), opts, sub.use)
+			},
+		})
+	}
+	return cmd
+}
+
+// runMigrate требует только настройки БД: миграции можно накатить отдельным шагом деплоя,
+// не передавая ему ключи подписи, Redis и прочие секреты сервиса
+func runMigrate(ctx context.Context, opts *rootOptions, command string) error {
+	cfg, err := util.LoadDBConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+	logger := util.NewZapLogger(util.NewLogLevel(&cfg.Log))
+
+	db, dbCleanup, err := util.NewDBConnection(ctx, logger, &cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer dbCleanup()
+
+	return migrations.Command(ctx, db, command)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/api"
+	"github.com/rryowa/medods_dvortsov/internal/controller"
+	"github.com/rryowa/medods_dvortsov/internal/metrics"
+	"github.com/rryowa/medods_dvortsov/internal/migrations"
+	"github.com/rryowa/medods_dvortsov/internal/service"
+	"github.com/rryowa/medods_dvortsov/internal/storage/postgres"
+	"github.com/rryowa/medods_dvortsov/internal/storage/redis"
+	"github.com/rryowa/medods_dvortsov/internal/storage/tracing"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+
+	_ "github.com/lib/pq"
+)
+
+func newServeCmd(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP API, admin server and background workers",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cfg, err := util.LoadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			serve(cfg, opts.configPath)
+			return nil
+		},
+	}
+}
+
+// serve собирает сервис и блокируется до graceful shutdown. Ошибки запуска фатальны
+func serve(cfg *util.Config, configPath string) {
+	ctx := context.Background()
+	logLevel := util.NewLogLevel(&cfg.Log)
+	logger := util.NewZapLogger(logLevel)
+
+	tracingCleanup, err := util.NewTracerProvider(ctx, &cfg.Tracing, logger)
+	if err != nil {
+		logger.Fatal(zap.Error(err))
+	}
+
+	db, dbCleanup, err := util.NewDBConnection(ctx, logger, &cfg.DB)
+	if err != nil {
+		logger.Fatal(zap.Error(err))
+	}
+	if cfg.DB.AutoMigrate {
+		if err := migrations.RunMigrations(db, logger); err != nil {
+			logger.Fatal(zap.Error(err))
+		}
+	}
+	schemaVersionCheck, err := migrations.NewVersionCheck(db)
+	if err != nil {
+		logger.Fatal(zap.Error(err))
+	}
+	// Без автомиграции схему накатывают отдельно (migrate up); со старой схемой сервис не стартует
+	if err := schemaVersionCheck(ctx); err != nil {
+		logger.Fatalf("%v: run `migrate up` or enable DB_AUTO_MIGRATE", err)
+	}
+
+	redisClient, redisCleanup, err := util.NewRedisClient(ctx, logger, &cfg.Redis)
+	if err != nil {
+		logger.Fatal(zap.Error(err))
+	}
+
+	appMetrics := metrics.NewMetrics()
+	appMetrics.RegisterDB(db)
+	appMetrics.RegisterRedis(redisClient)
+
+	storage := tracing.NewStorage(postgres.NewStorage(db))
+
+	apiKeyService := service.NewAPIKeyService(redisClient, storage, &cfg.APIKey, logger)
+	if err := apiKeyService.SyncAPIKey(ctx); err != nil {
+		logger.Fatal(zap.Error(err))
+	}
+
+	tokenConfig := &cfg.Token
+	keyRingService, err := service.NewKeyRingService(tokenConfig, storage, logger)
+	if err != nil {
+		logger.Fatal(zap.Error(err))
+	}
+	if err := keyRingService.SyncSigningKey(ctx); err != nil {
+		logger.Fatal(zap.Error(err))
+	}
+
+	sessionConfig := &cfg.Session
+	sessionReaper := service.NewSessionReaper(storage, redis.NewLocker(redisClient), sessionConfig, logger)
+	webhookService := service.NewWebhookService(&cfg.Webhook, storage, appMetrics, logger)
+	auditService := service.NewAuditService(storage, logger)
+	if err := webhookService.SyncEndpoint(ctx); err != nil {
+		logger.Fatal(zap.Error(err))
+	}
+
+	rateLimit := &atomic.Pointer[util.RateLimiterConfig]{}
+	rateLimit.Store(&cfg.RateLimit)
+	configReloader := service.NewConfigReloader(
+		configPath,
+		cfg,
+		logLevel,
+		rateLimit,
+		apiKeyService,
+		keyRingService,
+		webhookService,
+		logger,
+	)
+
+	// Фоновые задачи останавливаются до закрытия БД и Redis
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		configReloader.Run(workersCtx)
+	}()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		keyRingService.Run(workersCtx)
+	}()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		sessionReaper.Run(workersCtx)
+	}()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		webhookService.Run(workersCtx)
+	}()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		auditService.Run(workersCtx)
+	}()
+	stopWorkersAndWait := func() {
+		stopWorkers()
+		workers.Wait()
+	}
+	// Спаны досылаются последними: в них попадает и остановка воркеров
+	cleanupFuncs := []func(){stopWorkersAndWait, dbCleanup, redisCleanup, tracingCleanup}
+
+	tokenStorage := tracing.NewTokenStorage(redis.NewTokenStorage(redisClient))
+	tokenService := service.NewTokenService(tokenConfig, keyRingService, tokenStorage)
+	authService := service.NewAuthService(
+		tokenService,
+		storage,
+		webhookService,
+		appMetrics,
+		sessionConfig,
+		logger,
+	)
+
+	healthService := service.NewHealthService(logger)
+	healthService.AddCheck("postgres", db.PingContext)
+	healthService.AddCheck("redis", func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
+	healthService.AddCheck("migrations", schemaVersionCheck)
+	healthService.AddCheck("signing_key", keyRingService.CheckSigningKey)
+
+	controller := controller.NewController(
+		authService,
+		tokenService,
+		webhookService,
+		auditService,
+		apiKeyService,
+		logger,
+	)
+
+	apiServer := api.NewAPI(
+		controller,
+		authService,
+		apiKeyService,
+		redisClient,
+		appMetrics,
+		healthService,
+		cfg,
+		rateLimit,
+		logger,
+		cleanupFuncs,
+	)
+
+	apiServer.Run(ctx)
+}
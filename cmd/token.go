@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/service"
+	"github.com/rryowa/medods_dvortsov/internal/storage/postgres"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+var errTokenNotValid = errors.New("token is not valid")
+
+// tokenInspection вывод token inspect
+type tokenInspection struct {
+	Header   map[string]any `json:"header"`
+	Claims   jwt.MapClaims  `json:"claims"`
+	Verified *bool          `json:"verified,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+func newTokenCmd(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Work with issued tokens",
+	}
+
+	var noVerify bool
+	inspectCmd := &cobra.Command{
+		Use:   "inspect [TOKEN|-]",
+		Short: "Decode an access token and verify it against the key ring",
+		Long: "Decode the header and claims of an access token and verify its signature and expiry\n" +
+			"against the signing keys stored in Postgres. The denylist (revoked tokens) is not checked.\n" +
+			"The token is read from stdin if the argument is \"-\" or omitted. Exits with 1 if the token is not valid.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token, err := readTokenArg(cmd.InOrStdin(), args)
+			if err != nil {
+				return err
+			}
+			return inspectToken(cmd.Context(), cmd.OutOrStdout(), opts, token, !noVerify)
+		},
+	}
+	inspectCmd.Flags().BoolVar(&noVerify, "no-verify", false, "only decode the token, do not connect to the database")
+
+	cmd.AddCommand(inspectCmd)
+	return cmd
+}
+
+func readTokenArg(stdin io.Reader, args []string) (string, error) {
+	if len(args) == 1 && args[0] != "-" {
+		return strings.TrimSpace(args[0]), nil
+	}
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read token: %w", err)
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "Bearer "))
+	if token == "" {
+		return "", errors.New("token is empty")
+	}
+	return token, nil
+}
+
+func inspectToken(ctx context.Context, out io.Writer, opts *rootOptions, token string, verify bool) error {
+	claims := jwt.MapClaims{}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	if err != nil {
+		return fmt.Errorf("decode token: %w", err)
+	}
+	result := tokenInspection{Header: parsed.Header, Claims: claims}
+
+	var verifyErr error
+	if verify {
+		verifier, err := loadTokenVerifier(ctx, opts)
+		if err != nil {
+			return err
+		}
+		verifyErr = verifier.VerifyAccessToken(token)
+		verified := verifyErr == nil
+		result.Verified = &verified
+		if verifyErr != nil {
+			result.Error = verifyErr.Error()
+		}
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("encode token: %w", err)
+	}
+	if verifyErr != nil {
+		return errTokenNotValid
+	}
+	return nil
+}
+
+// loadTokenVerifier загружает связку ключей из БД. Связка читается целиком в память,
+// поэтому соединение закрывается сразу
+func loadTokenVerifier(ctx context.Context, opts *rootOptions) (*service.TokenService, error) {
+	cfg, err := util.LoadConfig(opts.configPath)
+	if err != nil {
+		return nil, err
+	}
+	// Логи подключения не нужны: stdout занят JSON, ошибки возвращаются
+	logger := zap.NewNop().Sugar()
+
+	db, dbCleanup, err := util.NewDBConnection(ctx, logger, &cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+	defer dbCleanup()
+
+	keyRing, err := service.NewKeyRingService(&cfg.Token, postgres.NewStorage(db), logger)
+	if err != nil {
+		return nil, err
+	}
+	// Только чтение связки: в отличие от serve, ключ из конфигурации в связку не добавляется
+	if err := keyRing.Reload(ctx); err != nil {
+		return nil, fmt.Errorf("load key ring: %w", err)
+	}
+
+	return service.NewTokenService(&cfg.Token, keyRing, nil), nil
+}
@@ -3,8 +3,11 @@ package api
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
+	"net/netip"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -14,25 +17,36 @@ import (
 	echomiddleware "github.com/labstack/echo/v4/middleware"
 	middleware "github.com/oapi-codegen/echo-middleware"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 	"go.uber.org/zap"
 
 	"github.com/rryowa/medods_dvortsov/internal/controller"
+	"github.com/rryowa/medods_dvortsov/internal/metrics"
 	"github.com/rryowa/medods_dvortsov/internal/service"
 	"github.com/rryowa/medods_dvortsov/internal/util"
 )
 
 const (
 	shutdownTimeout = 5 * time.Second
+	// jwksPath путь JWKS от корня сервера (RFC 8615): там его по умолчанию ищут библиотеки проверки JWT.
+	// Тот же обработчик доступен и в /api/v1
+	jwksPath = "/.well-known/jwks.json"
 )
 
 type API struct {
 	server          *echo.Echo
+	adminServer     *http.Server
+	metrics         *metrics.Metrics
+	health          *service.HealthService
 	controller      *controller.Controller
 	authService     *service.AuthService
 	apiKeyService   *service.APIKeyService
 	rdb             *redis.Client
 	log             *zap.SugaredLogger
 	gracefulTimeout time.Duration
+	shutdownDrain   time.Duration
+	rateLimitConfig *atomic.Pointer[util.RateLimiterConfig]
+	serviceName     string
 	shutdownFuncs   []func()
 }
 
@@ -41,30 +55,64 @@ func NewAPI(
 	authService *service.AuthService,
 	aks *service.APIKeyService,
 	rdb *redis.Client,
-	sc *util.ServerConfig,
+	m *metrics.Metrics,
+	health *service.HealthService,
+	cfg *util.Config,
+	rateLimit *atomic.Pointer[util.RateLimiterConfig],
 	l *zap.SugaredLogger,
 	shutdownFuncs []func(),
 ) *API {
 	e := echo.New()
+	sc := &cfg.Server
 
 	e.Server.Addr = sc.ServerAddr
 	e.Server.WriteTimeout = sc.WriteTimeout
 	e.Server.ReadTimeout = sc.ReadTimeout
 	e.Server.IdleTimeout = sc.IdleTimeout
 	e.HTTPErrorHandler = ErrorHandler(l)
+	trustedProxies, err := sc.TrustedProxyPrefixes()
+	if err != nil {
+		// Конфигурация уже проверена в LoadConfig; без списка заголовкам не доверяем
+		l.Errorw("invalid trusted proxies, ignoring them", "error", err)
+	}
+	e.IPExtractor = newIPExtractor(trustedProxies)
 
 	return &API{
 		server:          e,
+		adminServer:     newAdminServer(m, health, sc, l),
+		metrics:         m,
+		health:          health,
 		controller:      c,
 		authService:     authService,
 		log:             l,
 		gracefulTimeout: sc.GracefulTimeout,
+		shutdownDrain:   sc.ShutdownDrain,
+		rateLimitConfig: rateLimit,
+		serviceName:     cfg.Tracing.ServiceName,
 		rdb:             rdb,
 		apiKeyService:   aks,
 		shutdownFuncs:   shutdownFuncs,
 	}
 }
 
+// newIPExtractor определяет IP клиента для лимитов, allowlist и привязки сессий. X-Forwarded-For
+// учитывается только от доверенных прокси, иначе клиент подставил бы любой адрес: обошел бы лимиты
+// или попал в allowlist. Без доверенных прокси используется адрес соединения
+func newIPExtractor(trustedProxies []netip.Prefix) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	// По умолчанию echo доверяет loopback, link-local и частным сетям - оставляем только заданные
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, prefix := range trustedProxies {
+		options = append(options, echo.TrustIPRange(&net.IPNet{
+			IP:   prefix.Addr().AsSlice(),
+			Mask: net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen()),
+		}))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
 func (a *API) Run(ctxBackground context.Context) {
 	ctx, stop := signal.NotifyContext(ctxBackground, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -74,10 +122,14 @@ func (a *API) Run(ctxBackground context.Context) {
 		a.log.Fatalf("Failed to load OpenAPI specification: %v", err)
 	}
 
-	rateLimiterConfig := util.NewRateLimiterConfig()
-
+	// Спан запроса (с traceparent клиента, если он есть) - первым, чтобы в него попали остальные middleware
+	a.server.Use(otelecho.Middleware(a.serviceName))
+	ops := operationIDs(swagger, "/api/v1")
+	ops[http.MethodGet+" "+jwksPath] = ops[http.MethodGet+" /api/v1"+jwksPath]
+	a.server.Use(MetricsMiddleware(a.metrics, ops))
 	a.server.Use(echomiddleware.RequestLoggerWithConfig(LoggerMiddlewareConfig(a)))
-	a.server.Use(RateLimiter(a.rdb, a.log, rateLimiterConfig))
+	rateLimiter := NewRateLimiter(a.rdb, a.log, a.rateLimitConfig, ops, a.metrics)
+	a.server.Use(rateLimiter.PreAuth())
 
 	/*
 		Сгенерированный код сетапит маршруты OpenAPI и
@@ -98,8 +150,12 @@ func (a *API) Run(ctxBackground context.Context) {
 	}
 	validator := middleware.OapiRequestValidatorWithOptions(swagger, validatorOptions)
 
+	// JWKS публичный и без параметров, поэтому в корне обходится без валидатора OpenAPI
+	a.server.GET(jwksPath, openAPIWrapper.GetJWKS)
+
 	v1 := a.server.Group("/api/v1")
-	v1.Use(validator)
+	// Лимиты по API-ключу и пользователю - после аутентификации в validator
+	v1.Use(validator, rateLimiter.PostAuth())
 
 	controller.RegisterHandlers(v1, openAPIWrapper.Handler)
 
@@ -113,10 +169,26 @@ func (a *API) ListenGracefulShutdown(ctx context.Context) {
 			a.log.Fatalf("HTTP server ListenAndServe: %v", err)
 		}
 	}()
+	go func() {
+		err := a.adminServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.log.Fatalf("Admin server ListenAndServe: %v", err)
+		}
+	}()
 	a.log.Infof("Listening on: %s", a.server.Server.Addr)
+	a.log.Infof("Admin server listening on: %s", a.adminServer.Addr)
 	a.log.Infof("random uuid: %s", uuid.New().String())
 
 	<-ctx.Done()
+	// Сначала снимаем готовность: балансировщик перестает слать новые запросы,
+	// пока сервер дорабатывает текущие
+	a.health.SetShuttingDown()
+	// Балансировщик замечает упавший /readyz только со следующей проверкой:
+	// до этого новые запросы еще приходят и должны обслуживаться, а не получать connection refused
+	if a.shutdownDrain > 0 {
+		a.log.Infof("Draining for %s before shutdown...", a.shutdownDrain)
+		time.Sleep(a.shutdownDrain)
+	}
 	a.log.Info("Shutting down server...")
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
@@ -126,6 +198,9 @@ func (a *API) ListenGracefulShutdown(ctx context.Context) {
 	if err != nil {
 		a.log.Errorf("shutdown: %v", err)
 	}
+	if err := a.adminServer.Shutdown(shutdownCtx); err != nil {
+		a.log.Errorf("admin server shutdown: %v", err)
+	}
 
 	// После остановки сервера, офаем БД и Redis
 	// ыql.DB.Close() ждет пока запросы обработаются
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/service"
+)
+
+// healthzHandler liveness: процесс жив и отвечает, зависимости не проверяются
+func healthzHandler(w http.ResponseWriter, _ *http.Request) {
+	writeHealthJSON(w, http.StatusOK, map[string]string{"status": models.HealthStatusOK}, nil)
+}
+
+// readyzHandler readiness: 503 с деталями, если не прошла хотя бы одна проверка
+func readyzHandler(health *service.HealthService, l *zap.SugaredLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := health.Readiness(r.Context())
+		status := http.StatusOK
+		if report.Status != models.HealthStatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeHealthJSON(w, status, report, l)
+	}
+}
+
+func writeHealthJSON(w http.ResponseWriter, status int, body any, l *zap.SugaredLogger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil && l != nil {
+		l.Errorf("write health response: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/metrics"
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/service"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+// operationIDs сопоставляет маршрут echo ("POST /api/v1/auth/sessions/:session_id") с operationId.
+// Метки метрик берутся из спецификации, а не из пути запроса: иначе каждый id в пути - новая серия.
+func operationIDs(swagger *openapi3.T, prefix string) map[string]string {
+	ops := make(map[string]string)
+	for path, item := range swagger.Paths.Map() {
+		route := prefix + strings.NewReplacer("{", ":", "}", "").Replace(path)
+		for method, op := range item.Operations() {
+			ops[method+" "+route] = op.OperationID
+		}
+	}
+	return ops
+}
+
+// MetricsMiddleware пишет длительность запроса с меткой операции OpenAPI и кладет operationId в контекст
+// для следующих middleware. Ошибку обработчика отдает в HTTPErrorHandler сам, чтобы учесть итоговый статус,
+// поэтому должен стоять первым.
+func MetricsMiddleware(m *metrics.Metrics, ops map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			operation, ok := ops[c.Request().Method+" "+c.Path()]
+			if !ok {
+				operation = metrics.UnknownOperation
+			}
+			c.Set(models.MwOperationIDKey, operation)
+			trace.SpanFromContext(c.Request().Context()).SetAttributes(attribute.String("openapi.operation_id", operation))
+
+			start := time.Now()
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			m.HTTPRequestDuration.
+				WithLabelValues(operation, statusOutcome(c.Response().Status)).
+				Observe(time.Since(start).Seconds())
+			return nil
+		}
+	}
+}
+
+// statusOutcome класс ответа ("2xx", "4xx", ...): точный код дал бы лишние серии
+func statusOutcome(status int) string {
+	if status < http.StatusContinue || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// operationID метка операции, выставленная MetricsMiddleware
+func operationID(c echo.Context) string {
+	if operation, ok := c.Get(models.MwOperationIDKey).(string); ok {
+		return operation
+	}
+	return metrics.UnknownOperation
+}
+
+// newAdminServer сервер служебных эндпоинтов (/metrics) на отдельном порту,
+// который не публикуется наружу вместе с API
+func newAdminServer(m *metrics.Metrics, health *service.HealthService, sc *util.ServerConfig, l *zap.SugaredLogger) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	mux.HandleFunc("GET /healthz", healthzHandler)
+	mux.HandleFunc("GET /readyz", readyzHandler(health, l))
+	return &http.Server{
+		Addr:         sc.AdminAddr,
+		Handler:      mux,
+		ReadTimeout:  sc.ReadTimeout,
+		WriteTimeout: sc.WriteTimeout,
+		IdleTimeout:  sc.IdleTimeout,
+	}
+}
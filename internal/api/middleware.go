@@ -5,19 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
 	middleware "github.com/oapi-codegen/echo-middleware"
-	"github.com/redis/go-redis/v9"
-	"go.uber.org/zap"
 
 	"github.com/rryowa/medods_dvortsov/internal/models"
 	"github.com/rryowa/medods_dvortsov/internal/service"
-	"github.com/rryowa/medods_dvortsov/internal/util"
 )
 
 func NewAuthenticator(
@@ -36,13 +32,21 @@ func NewAuthenticator(
 			if apiKey == "" {
 				return echo.NewHTTPError(http.StatusUnauthorized, "API key is missing")
 			}
-			valid, err := apiKeyService.IsValidAPIKey(ctx, apiKey)
+			identity, err := apiKeyService.Authenticate(ctx, apiKey)
 			if err != nil {
+				if errors.Is(err, service.ErrInvalidAPIKey) {
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
+				}
 				return fmt.Errorf("failed during api key validation: %w", err)
 			}
-			if !valid {
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
+			if !identity.HasScopes(input.Scopes) {
+				return echo.NewHTTPError(http.StatusForbidden, "API key lacks required scope")
 			}
+
+			// Ключ нужен логам и лимитам (echo.Context) и аудиту в сервисах (context.Context)
+			echoCtx.Set(models.MwAPIKeyKey, identity)
+			req := echoCtx.Request()
+			echoCtx.SetRequest(req.WithContext(models.ContextWithAPIKey(req.Context(), identity)))
 			return nil
 
 		case models.MwSchemeBearerAuth:
@@ -78,84 +82,6 @@ func NewAuthenticator(
 	}
 }
 
-// RateLimiter middleware
-//
-//	interval: окно для подсчета запросов
-//	blockTime: Длительность блокировки после превышения лимита (0 = без блокировки)
-func RateLimiter(
-	redisClient *redis.Client,
-	_ *zap.SugaredLogger,
-	config *util.RateLimiterConfig,
-) echo.MiddlewareFunc {
-	/*
-		Без Lua скрипта:
-		Между INCR и ExpireNX могут вклиниться другие запросы
-		Race confidtion при увеличении счетчика
-	*/
-	// Атомарное выполнение
-	script := redis.NewScript(`
-        local count_key = KEYS[1]
-		local limit = tonumber(ARGV[1])
-		local interval = tonumber(ARGV[2])
-		local block_time = tonumber(ARGV[3])
-
-		local current = redis.call("GET", count_key)
-		current = current and tonumber(current) or 0
-
-		if current >= limit then
-			local block_key = count_key .. ":block"
-			local blocked = redis.call("GET", block_key)
-			
-			if not blocked then
-				-- Блокируемся
-				redis.call("SETEX", block_key, block_time, "1")
-			end
-			
-			-- Превышен лимит
-			return 0
-		end
-
-		redis.call("INCR", count_key)
-
-		-- TTL при первом запросе
-		if current == 0 then
-			redis.call("EXPIRE", count_key, interval)
-		end
-
-		-- Ок
-		return 1
-    `)
-
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			ipAddress := c.RealIP()
-			key := "rate_limit:" + ipAddress
-
-			// Атомарно
-			result, err := script.Run(
-				c.Request().Context(),
-				redisClient,
-				[]string{key},
-				config.Limit,
-				int(config.Interval.Seconds()),
-				int(config.BlockTime.Seconds()),
-			).Int()
-			if err != nil {
-				c.Logger().Errorf("Rate limiter error: %v", err)
-				return next(c) // Пропускаем запрос
-			}
-
-			if result == 0 {
-				// Превышение лимита
-				c.Response().Header().Set("Retry-After", strconv.Itoa(int(config.BlockTime.Seconds())))
-				return echo.NewHTTPError(http.StatusTooManyRequests, "Too many requests")
-			}
-
-			return next(c)
-		}
-	}
-}
-
 func LoggerMiddlewareConfig(a *API) echomiddleware.RequestLoggerConfig {
 	return echomiddleware.RequestLoggerConfig{
 		LogMethod: true,
@@ -169,6 +95,9 @@ func LoggerMiddlewareConfig(a *API) echomiddleware.RequestLoggerConfig {
 				"uri", v.URI,
 				"status", v.Status,
 			}
+			if identity, ok := c.Get(models.MwAPIKeyKey).(*models.APIKeyIdentity); ok {
+				fields = append(fields, "api_key", identity.Name)
+			}
 			if v.Error != nil {
 				fields = append(fields, "error", fmt.Sprintf("%+v", v.Error))
 				a.log.Errorw("Request", fields...)
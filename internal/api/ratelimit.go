@@ -0,0 +1,291 @@
+package api
+
+import (
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/metrics"
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+/*
+Без Lua скрипта:
+Между INCR и ExpireNX могут вклиниться другие запросы
+Race confidtion при увеличении счетчика
+*/
+// Атомарное выполнение
+//
+//nolint:gochecknoglobals // скрипт неизменяемый, redis кэширует его по sha
+var rateLimitScript = redis.NewScript(`
+	local count_key = KEYS[1]
+	local block_key = count_key .. ":block"
+	local limit = tonumber(ARGV[1])
+	local interval = tonumber(ARGV[2])
+	local block_time = tonumber(ARGV[3])
+
+	-- Заблокированы, пока не истечет block_time, даже если окно уже сбросилось
+	if redis.call("EXISTS", block_key) == 1 then
+		return 0
+	end
+
+	local current = redis.call("GET", count_key)
+	current = current and tonumber(current) or 0
+
+	if current >= limit then
+		if block_time > 0 then
+			-- Блокируемся
+			redis.call("SET", block_key, "1", "EX", block_time)
+		end
+
+		-- Превышен лимит
+		return 0
+	end
+
+	redis.call("INCR", count_key)
+
+	-- TTL при первом запросе
+	if current == 0 then
+		redis.call("EXPIRE", count_key, interval)
+	end
+
+	-- Ок
+	return 1
+`)
+
+// rateLimitRule лимит, который считается отдельно для каждого значения key
+//
+//	interval: окно для подсчета запросов
+//	blockTime: Длительность блокировки после превышения лимита (0 = без блокировки)
+type rateLimitRule struct {
+	// name пустое у лимита по умолчанию: его счетчики остаются под прежними ключами rate_limit:<ip>
+	name      string
+	key       string
+	limit     int
+	interval  time.Duration
+	blockTime time.Duration
+}
+
+// rateLimitRules разобранная конфигурация лимитов. Пересобирается, когда SIGHUP подменяет конфигурацию
+type rateLimitRules struct {
+	source      *util.RateLimiterConfig
+	allowlist   []netip.Prefix
+	fallback    rateLimitRule
+	byOperation map[string][]rateLimitRule
+}
+
+// RateLimiter ограничивает число запросов по политикам из конфигурации.
+// Лимиты по ip и guid проверяются до аутентификации (PreAuth), чтобы отсекать перебор ключей и токенов,
+// по api_key и user - после нее (PostAuth), когда известно, кто вызывает.
+// Операции без своей политики по ip ограничиваются лимитом по IP по умолчанию.
+type RateLimiter struct {
+	rdb        *redis.Client
+	log        *zap.SugaredLogger
+	liveConfig *atomic.Pointer[util.RateLimiterConfig]
+	operations map[string]struct{}
+	m          *metrics.Metrics
+	rules      atomic.Pointer[rateLimitRules]
+}
+
+// NewRateLimiter ops - операции OpenAPI (см. operationIDs): по ним проверяются имена операций в политиках
+func NewRateLimiter(
+	rdb *redis.Client,
+	log *zap.SugaredLogger,
+	liveConfig *atomic.Pointer[util.RateLimiterConfig],
+	ops map[string]string,
+	m *metrics.Metrics,
+) *RateLimiter {
+	operations := make(map[string]struct{}, len(ops))
+	for _, operation := range ops {
+		operations[operation] = struct{}{}
+	}
+	return &RateLimiter{rdb: rdb, log: log, liveConfig: liveConfig, operations: operations, m: m}
+}
+
+// PreAuth middleware до валидации OpenAPI: allowlist, лимит по умолчанию и политики по ip и guid
+func (rl *RateLimiter) PreAuth() echo.MiddlewareFunc {
+	return rl.middleware(false)
+}
+
+// PostAuth middleware после аутентификации: политики по api_key и user
+func (rl *RateLimiter) PostAuth() echo.MiddlewareFunc {
+	return rl.middleware(true)
+}
+
+func (rl *RateLimiter) middleware(postAuth bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Лимиты меняются на лету (SIGHUP), запрос работает с одним снимком
+			rules := rl.load()
+			if rules.allowed(c.RealIP()) {
+				return next(c)
+			}
+
+			policies, ok := rules.byOperation[operationID(c)]
+			if !ok {
+				if postAuth {
+					return next(c)
+				}
+				policies = []rateLimitRule{rules.fallback}
+			}
+
+			for _, rule := range policies {
+				if isPostAuthKey(rule.key) != postAuth {
+					continue
+				}
+				// Значения ключа может не быть (user у запроса по API-ключу) - политика к запросу не относится
+				subject, ok := rateLimitSubject(c, rule.key)
+				if !ok {
+					continue
+				}
+				if err := rl.take(c, rule, subject); err != nil {
+					return err
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func (rl *RateLimiter) take(c echo.Context, rule rateLimitRule, subject string) error {
+	key := "rate_limit:" + subject
+	if rule.name != "" {
+		key = "rate_limit:" + rule.name + ":" + subject
+	}
+
+	// Атомарно
+	result, err := rateLimitScript.Run(
+		c.Request().Context(),
+		rl.rdb,
+		[]string{key},
+		rule.limit,
+		int(rule.interval.Seconds()),
+		int(rule.blockTime.Seconds()),
+	).Int()
+	if err != nil {
+		rl.log.Errorw("Rate limiter error", "policy", rule.name, "error", err)
+		return nil // Пропускаем запрос
+	}
+
+	if result == 0 {
+		// Превышение лимита
+		rl.m.RateLimitRejections.WithLabelValues(operationID(c)).Inc()
+		retryAfter := rule.blockTime
+		if retryAfter == 0 {
+			retryAfter = rule.interval
+		}
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		return echo.NewHTTPError(http.StatusTooManyRequests, "Too many requests")
+	}
+	return nil
+}
+
+func (rl *RateLimiter) load() *rateLimitRules {
+	cfg := rl.liveConfig.Load()
+	if rules := rl.rules.Load(); rules != nil && rules.source == cfg {
+		return rules
+	}
+	rules := rl.compile(cfg)
+	rl.rules.Store(rules)
+	return rules
+}
+
+func (rl *RateLimiter) compile(cfg *util.RateLimiterConfig) *rateLimitRules {
+	allowlist, err := cfg.AllowlistPrefixes()
+	if err != nil {
+		// Конфигурация уже проверена в LoadConfig
+		rl.log.Errorw("invalid rate limit allowlist, ignoring it", "error", err)
+	}
+
+	rules := &rateLimitRules{
+		source:    cfg,
+		allowlist: allowlist,
+		fallback: rateLimitRule{
+			key:       models.RateLimitKeyIP,
+			limit:     cfg.Limit,
+			interval:  cfg.Interval,
+			blockTime: cfg.BlockTime,
+		},
+		byOperation: make(map[string][]rateLimitRule),
+	}
+	for _, p := range cfg.Policies {
+		rule := rateLimitRule{
+			name:      p.Name,
+			key:       p.Key,
+			limit:     p.Limit,
+			interval:  p.Interval,
+			blockTime: p.BlockTime,
+		}
+		for _, operation := range p.Operations {
+			if _, ok := rl.operations[operation]; !ok {
+				rl.log.Warnw("rate limit policy refers to unknown operation", "policy", p.Name, "operation", operation)
+			}
+			rules.byOperation[operation] = append(rules.byOperation[operation], rule)
+		}
+	}
+	// Политики по api_key и user проверяются только после аутентификации: без своей политики по ip
+	// операция остается под лимитом по умолчанию, иначе перебор ключей и токенов ничем не ограничен
+	for operation, policies := range rules.byOperation {
+		hasIP := slices.ContainsFunc(policies, func(rule rateLimitRule) bool {
+			return rule.key == models.RateLimitKeyIP
+		})
+		if !hasIP {
+			rules.byOperation[operation] = append(policies, rules.fallback)
+		}
+	}
+	return rules
+}
+
+func (r *rateLimitRules) allowed(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	return slices.ContainsFunc(r.allowlist, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	})
+}
+
+func isPostAuthKey(key string) bool {
+	return key == models.RateLimitKeyAPIKey || key == models.RateLimitKeyUser
+}
+
+func rateLimitSubject(c echo.Context, key string) (string, bool) {
+	switch key {
+	case models.RateLimitKeyIP:
+		return c.RealIP(), true
+	case models.RateLimitKeyGUID:
+		// До валидации OpenAPI: в ключ Redis попадает только корректный GUID
+		guid, err := uuid.Parse(c.QueryParam("guid"))
+		if err != nil {
+			return "", false
+		}
+		return "guid:" + guid.String(), true
+	case models.RateLimitKeyAPIKey:
+		identity, ok := c.Get(models.MwAPIKeyKey).(*models.APIKeyIdentity)
+		if !ok {
+			return "", false
+		}
+		// По имени: ротация выпускает ключ с тем же именем, и счетчик не сбрасывается
+		return "api_key:" + identity.Name, true
+	case models.RateLimitKeyUser:
+		userID, ok := c.Get(models.MwUserIDKey).(int64)
+		if !ok {
+			return "", false
+		}
+		return "user:" + strconv.FormatInt(userID, 10), true
+	default:
+		return "", false
+	}
+}
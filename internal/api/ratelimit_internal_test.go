@@ -0,0 +1,158 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+func TestRateLimitSubject(t *testing.T) {
+	const guid = "0e9c1d3f-7c1a-4c5e-9a0b-2f6d8e4b1a37"
+
+	tests := []struct {
+		name   string
+		key    string
+		target string
+		set    map[string]any
+		want   string
+		wantOK bool
+	}{
+		{name: "ip", key: models.RateLimitKeyIP, target: "/", want: "192.0.2.1", wantOK: true},
+		{name: "guid", key: models.RateLimitKeyGUID, target: "/?guid=" + guid, want: "guid:" + guid, wantOK: true},
+		{
+			name:   "guid is normalized",
+			key:    models.RateLimitKeyGUID,
+			target: "/?guid={0E9C1D3F-7C1A-4C5E-9A0B-2F6D8E4B1A37}",
+			want:   "guid:" + guid,
+			wantOK: true,
+		},
+		{name: "invalid guid", key: models.RateLimitKeyGUID, target: "/?guid=admin"},
+		{name: "no guid", key: models.RateLimitKeyGUID, target: "/"},
+		{
+			name:   "api key by name",
+			key:    models.RateLimitKeyAPIKey,
+			target: "/",
+			set:    map[string]any{models.MwAPIKeyKey: &models.APIKeyIdentity{Name: "billing"}},
+			want:   "api_key:billing",
+			wantOK: true,
+		},
+		{name: "no api key", key: models.RateLimitKeyAPIKey, target: "/"},
+		{
+			name:   "user",
+			key:    models.RateLimitKeyUser,
+			target: "/",
+			set:    map[string]any{models.MwUserIDKey: int64(42)},
+			want:   "user:42",
+			wantOK: true,
+		},
+		{
+			name:   "user of another type",
+			key:    models.RateLimitKeyUser,
+			target: "/",
+			set:    map[string]any{models.MwUserIDKey: "42"},
+		},
+		{name: "no user", key: models.RateLimitKeyUser, target: "/"},
+		{name: "unknown key", key: "cookie", target: "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.RemoteAddr = "192.0.2.1:12345"
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			for key, value := range tt.set {
+				c.Set(key, value)
+			}
+
+			got, ok := rateLimitSubject(c, tt.key)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("rateLimitSubject() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRateLimitAllowlistClientIP(t *testing.T) {
+	rl := &RateLimiter{log: zap.NewNop().Sugar()}
+	rules := rl.compile(&util.RateLimiterConfig{Allowlist: []string{"127.0.0.1"}})
+	proxy := netip.MustParsePrefix("192.0.2.0/24")
+
+	tests := []struct {
+		name           string
+		trustedProxies []netip.Prefix
+		remoteAddr     string
+		forwardedFor   string
+		realIP         string
+		want           string
+		wantAllowed    bool
+	}{
+		{
+			name:         "spoofed forwarded for without trusted proxies",
+			remoteAddr:   "203.0.113.5:12345",
+			forwardedFor: "127.0.0.1",
+			want:         "203.0.113.5",
+		},
+		{
+			name:       "spoofed real ip without trusted proxies",
+			remoteAddr: "203.0.113.5:12345",
+			realIP:     "127.0.0.1",
+			want:       "203.0.113.5",
+		},
+		{
+			name:           "spoofed forwarded for from an untrusted address",
+			trustedProxies: []netip.Prefix{proxy},
+			remoteAddr:     "203.0.113.5:12345",
+			forwardedFor:   "127.0.0.1",
+			want:           "203.0.113.5",
+		},
+		{
+			name:           "private networks are not trusted implicitly",
+			trustedProxies: []netip.Prefix{proxy},
+			remoteAddr:     "10.0.0.1:12345",
+			forwardedFor:   "127.0.0.1",
+			want:           "10.0.0.1",
+		},
+		{
+			name:           "forwarded for from a trusted proxy",
+			trustedProxies: []netip.Prefix{proxy},
+			remoteAddr:     "192.0.2.10:12345",
+			forwardedFor:   "127.0.0.1",
+			want:           "127.0.0.1",
+			wantAllowed:    true,
+		},
+		{
+			name:        "direct allowlisted address",
+			remoteAddr:  "127.0.0.1:12345",
+			want:        "127.0.0.1",
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.IPExtractor = newIPExtractor(tt.trustedProxies)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				req.Header.Set(echo.HeaderXRealIP, tt.realIP)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			if got := c.RealIP(); got != tt.want {
+				t.Errorf("RealIP() = %q, want %q", got, tt.want)
+			}
+			if got := rules.allowed(c.RealIP()); got != tt.wantAllowed {
+				t.Errorf("allowed() = %v, want %v", got, tt.wantAllowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/service"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+// ListAPIKeys (GET /api/v1/api-keys)
+func (c *Controller) ListAPIKeys(ctx echo.Context) error {
+	keys, err := c.apiKeyService.ListKeys(ctx.Request().Context())
+	if err != nil {
+		return fmt.Errorf("list api keys: %w", err)
+	}
+
+	resp := APIKeysResponse{ApiKeys: make([]APIKey, 0, len(keys))}
+	for _, k := range keys {
+		resp.ApiKeys = append(resp.ApiKeys, toAPIKey(k))
+	}
+
+	if err := ctx.JSON(http.StatusOK, resp); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// CreateAPIKey (POST /api/v1/api-keys)
+func (c *Controller) CreateAPIKey(ctx echo.Context) error {
+	var req APIKeyCreateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	key, created, err := c.apiKeyService.CreateKey(ctx.Request().Context(), req.Name, scopes, req.ExpiresAt)
+	if err != nil {
+		return apiKeyError(err)
+	}
+
+	// Ключ показывается только при выпуске
+	if err := ctx.JSON(http.StatusCreated, toAPIKeyCreated(key, *created)); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// RevokeAPIKey (DELETE /api/v1/api-keys/{key_id})
+func (c *Controller) RevokeAPIKey(ctx echo.Context, keyID int64) error {
+	if err := c.apiKeyService.RevokeKey(ctx.Request().Context(), keyID); err != nil {
+		return apiKeyError(err)
+	}
+
+	if err := ctx.NoContent(http.StatusNoContent); err != nil {
+		return fmt.Errorf("no content: %w", err)
+	}
+	return nil
+}
+
+// RotateAPIKey (POST /api/v1/api-keys/{key_id}/rotate)
+func (c *Controller) RotateAPIKey(ctx echo.Context, keyID int64) error {
+	var req APIKeyRotateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	var overlap *time.Duration
+	if req.OverlapSeconds != nil {
+		// Слишком большое значение переполнит time.Duration и станет отрицательным или маленьким,
+		// поэтому значение больше предела заменяется на заведомо недопустимое, его отклонит RotateKey
+		d := service.MaxAPIKeyRotationOverlap + time.Second
+		if *req.OverlapSeconds <= int64(service.MaxAPIKeyRotationOverlap/time.Second) {
+			d = time.Duration(*req.OverlapSeconds) * time.Second
+		}
+		overlap = &d
+	}
+
+	key, created, err := c.apiKeyService.RotateKey(ctx.Request().Context(), keyID, overlap, req.ExpiresAt)
+	if err != nil {
+		return apiKeyError(err)
+	}
+
+	if err := ctx.JSON(http.StatusCreated, toAPIKeyCreated(key, *created)); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+func apiKeyError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrAPIKeyNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "api key not found")
+	case errors.Is(err, service.ErrInvalidAPIKeyRequest):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return err
+	}
+}
+
+func toAPIKey(k models.APIKey) APIKey {
+	scopes := make([]APIKeyScope, 0, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		scopes = append(scopes, APIKeyScope(scope))
+	}
+
+	return APIKey{
+		Id:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+
+func toAPIKeyCreated(key string, k models.APIKey) APIKeyCreated {
+	resp := toAPIKey(k)
+	return APIKeyCreated{
+		Id:         resp.Id,
+		Name:       resp.Name,
+		Prefix:     resp.Prefix,
+		Scopes:     resp.Scopes,
+		CreatedAt:  resp.CreatedAt,
+		ExpiresAt:  resp.ExpiresAt,
+		LastUsedAt: resp.LastUsedAt,
+		RevokedAt:  resp.RevokedAt,
+		Key:        key,
+	}
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/service"
+)
+
+const defaultAuditEventsLimit = 100
+
+//nolint:gochecknoglobals // заголовок CSV-выгрузки
+var auditCSVHeader = []string{
+	"id", "occurred_at", "event_type", "actor", "user_id", "user_guid", "session_id",
+	"ip_address", "user_agent", "outcome", "reason", "prev_hash", "hash",
+}
+
+// ListAuditEvents (GET /api/v1/audit/events)
+func (c *Controller) ListAuditEvents(ctx echo.Context, params ListAuditEventsParams) error {
+	filter := auditFilter(params.UserGuid, params.EventType, params.From, params.To, params.Ip)
+	limit := defaultAuditEventsLimit
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	var cursor string
+	if params.Cursor != nil {
+		cursor = *params.Cursor
+	}
+
+	events, nextCursor, err := c.auditService.ListEvents(ctx.Request().Context(), filter, cursor, limit)
+	if err != nil {
+		return auditError(err)
+	}
+
+	resp := AuditEventsResponse{
+		Events:     make([]AuditEvent, 0, len(events)),
+		NextCursor: optionalString(nextCursor),
+	}
+	for _, e := range events {
+		resp.Events = append(resp.Events, toAuditEvent(e))
+	}
+
+	if err := ctx.JSON(http.StatusOK, resp); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// ExportAuditEvents (GET /api/v1/audit/events/export)
+// Записи пишутся в ответ по мере чтения из БД. Если чтение оборвалось после начала ответа,
+// статус уже не изменить: выгрузка обрезается, ошибка логируется.
+func (c *Controller) ExportAuditEvents(ctx echo.Context, params ExportAuditEventsParams) error {
+	filter := auditFilter(params.UserGuid, params.EventType, params.From, params.To, params.Ip)
+	format := Jsonl
+	if params.Format != nil {
+		format = *params.Format
+	}
+
+	resp := ctx.Response()
+	csvWriter := csv.NewWriter(resp)
+	jsonEncoder := json.NewEncoder(resp)
+	started := false
+	start := func() error {
+		started = true
+		contentType, ext := "application/x-ndjson", "jsonl"
+		if format == Csv {
+			contentType, ext = "text/csv; charset=utf-8", "csv"
+		}
+		resp.Header().Set(echo.HeaderContentType, contentType)
+		resp.Header().Set(echo.HeaderContentDisposition, `attachment; filename="audit-events.`+ext+`"`)
+		resp.WriteHeader(http.StatusOK)
+		if format == Csv {
+			return csvWriter.Write(auditCSVHeader)
+		}
+		return nil
+	}
+
+	err := c.auditService.ExportEvents(ctx.Request().Context(), filter, func(events []models.AuditEvent) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for _, e := range events {
+			var err error
+			if format == Csv {
+				err = csvWriter.Write(toAuditCSVRecord(toAuditEvent(e)))
+			} else {
+				err = jsonEncoder.Encode(toAuditEvent(e))
+			}
+			if err != nil {
+				return fmt.Errorf("write audit event: %w", err)
+			}
+		}
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return fmt.Errorf("write audit events: %w", err)
+		}
+		resp.Flush()
+		return nil
+	})
+	if err != nil {
+		if !started {
+			return auditError(err)
+		}
+		c.log.Errorw("audit export aborted", "error", err)
+		return nil
+	}
+
+	// Под фильтр ничего не попало - пустая выгрузка (для CSV - только заголовок)
+	if !started {
+		if err := start(); err != nil {
+			return fmt.Errorf("write audit export: %w", err)
+		}
+		csvWriter.Flush()
+	}
+	return nil
+}
+
+func auditError(err error) error {
+	if errors.Is(err, service.ErrInvalidAuditCursor) || errors.Is(err, service.ErrInvalidAuditFilter) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return err
+}
+
+func auditFilter(
+	userGUID *AuditUserGUID,
+	eventType *AuditEventType,
+	from, to *time.Time,
+	ip *string,
+) models.AuditFilter {
+	filter := models.AuditFilter{From: from, To: to}
+	if userGUID != nil {
+		filter.UserGUID = userGUID.String()
+	}
+	if eventType != nil {
+		filter.EventType = string(*eventType)
+	}
+	if ip != nil {
+		filter.IPAddress = *ip
+	}
+	return filter
+}
+
+func toAuditEvent(e models.AuditEvent) AuditEvent {
+	event := AuditEvent{
+		Id:         e.ID,
+		OccurredAt: e.OccurredAt,
+		EventType:  AuditEventType(e.EventType),
+		Actor:      e.Actor,
+		UserId:     e.UserID,
+		SessionId:  e.SessionID,
+		IpAddress:  e.IPAddress,
+		UserAgent:  e.UserAgent,
+		Outcome:    AuditEventOutcome(e.Outcome),
+		Reason:     e.Reason,
+		PrevHash:   hex.EncodeToString(e.PrevHash),
+		Hash:       hex.EncodeToString(e.Hash),
+	}
+	if guid, err := uuid.Parse(e.UserGUID); err == nil {
+		event.UserGuid = &guid
+	}
+	return event
+}
+
+func toAuditCSVRecord(e AuditEvent) []string {
+	optionalInt := func(v *int64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatInt(*v, 10)
+	}
+	var guid string
+	if e.UserGuid != nil {
+		guid = e.UserGuid.String()
+	}
+	return []string{
+		strconv.FormatInt(e.Id, 10),
+		e.OccurredAt.Format(time.RFC3339Nano),
+		string(e.EventType),
+		e.Actor,
+		optionalInt(e.UserId),
+		guid,
+		optionalInt(e.SessionId),
+		e.IpAddress,
+		e.UserAgent,
+		string(e.Outcome),
+		e.Reason,
+		e.PrevHash,
+		e.Hash,
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/labstack/echo/v4"
@@ -24,11 +25,254 @@ const (
 	BearerAuthScopes = "BearerAuth.Scopes"
 )
 
+// Defines values for APIKeyScope.
+const (
+	APIKeyScopeAdmin            APIKeyScope = "api_keys:admin"
+	APIKeyScopeAll              APIKeyScope = "*"
+	APIKeyScopeAuditRead        APIKeyScope = "audit:read"
+	APIKeyScopeTokensIntrospect APIKeyScope = "tokens:introspect"
+	APIKeyScopeTokensIssue      APIKeyScope = "tokens:issue"
+	APIKeyScopeTokensRevoke     APIKeyScope = "tokens:revoke"
+	APIKeyScopeWebhooksRead     APIKeyScope = "webhooks:read"
+	APIKeyScopeWebhooksWrite    APIKeyScope = "webhooks:write"
+)
+
+// Defines values for AuditEventOutcome.
+const (
+	AuditOutcomeFailure AuditEventOutcome = "failure"
+	AuditOutcomeSuccess AuditEventOutcome = "success"
+)
+
+// Defines values for AuditEventType.
+const (
+	AuditLogout             AuditEventType = "logout"
+	AuditLogoutAll          AuditEventType = "logout.all"
+	AuditSessionRevoked     AuditEventType = "session.revoked"
+	AuditTokenIssued        AuditEventType = "token.issued"
+	AuditTokenRefreshed     AuditEventType = "token.refreshed"
+	AuditTokenReuseDetected AuditEventType = "token.reuse_detected"
+	AuditTokenRevoked       AuditEventType = "token.revoked"
+	AuditUserAgentMismatch  AuditEventType = "user_agent.mismatch"
+)
+
+// Defines values for DeviceType.
+const (
+	Bot     DeviceType = "bot"
+	Desktop DeviceType = "desktop"
+	Mobile  DeviceType = "mobile"
+	Other   DeviceType = "other"
+	Tablet  DeviceType = "tablet"
+)
+
+// Defines values for IntrospectionResponseTokenType.
+const (
+	IntrospectionResponseTokenTypeAccessToken  IntrospectionResponseTokenType = "access_token"
+	IntrospectionResponseTokenTypeRefreshToken IntrospectionResponseTokenType = "refresh_token"
+)
+
+// Defines values for JWKKty.
+const (
+	EC  JWKKty = "EC"
+	OKP JWKKty = "OKP"
+	RSA JWKKty = "RSA"
+)
+
+// Defines values for TokenRequestTokenTypeHint.
+const (
+	TokenRequestTokenTypeHintAccessToken  TokenRequestTokenTypeHint = "access_token"
+	TokenRequestTokenTypeHintRefreshToken TokenRequestTokenTypeHint = "refresh_token"
+)
+
+// Defines values for WebhookEventType.
+const (
+	All                WebhookEventType = "*"
+	IpChanged          WebhookEventType = "ip.changed"
+	Logout             WebhookEventType = "logout"
+	SessionCreated     WebhookEventType = "session.created"
+	SessionRefreshed   WebhookEventType = "session.refreshed"
+	SessionRevoked     WebhookEventType = "session.revoked"
+	TokenReuseDetected WebhookEventType = "token.reuse_detected"
+	UserAgentMismatch  WebhookEventType = "user_agent.mismatch"
+)
+
+// Defines values for WebhookFormat.
+const (
+	CloudeventsBinary     WebhookFormat = "cloudevents-binary"
+	CloudeventsStructured WebhookFormat = "cloudevents-structured"
+	Json                  WebhookFormat = "json"
+)
+
+// Defines values for ExportAuditEventsParamsFormat.
+const (
+	Csv   ExportAuditEventsParamsFormat = "csv"
+	Jsonl ExportAuditEventsParamsFormat = "jsonl"
+)
+
+// APIKey defines model for APIKey.
+type APIKey struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Id         int64      `json:"id"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Name       string     `json:"name"`
+
+	// Prefix Открытая часть ключа `ak_<prefix>_...`, по ней ключ узнается в логах
+	Prefix    string        `json:"prefix"`
+	RevokedAt *time.Time    `json:"revoked_at,omitempty"`
+	Scopes    []APIKeyScope `json:"scopes"`
+}
+
+// APIKeyCreateRequest defines model for APIKeyCreateRequest.
+type APIKeyCreateRequest struct {
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+	Name      string        `json:"name"`
+	Scopes    []APIKeyScope `json:"scopes"`
+}
+
+// APIKeyCreated defines model for APIKeyCreated.
+type APIKeyCreated struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Id        int64      `json:"id"`
+
+	// Key Сам ключ, передается в `X-API-Key`. Показывается один раз
+	Key        string     `json:"key"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Name       string     `json:"name"`
+
+	// Prefix Открытая часть ключа `ak_<prefix>_...`, по ней ключ узнается в логах
+	Prefix    string        `json:"prefix"`
+	RevokedAt *time.Time    `json:"revoked_at,omitempty"`
+	Scopes    []APIKeyScope `json:"scopes"`
+}
+
+// APIKeyRotateRequest defines model for APIKeyRotateRequest.
+type APIKeyRotateRequest struct {
+	// ExpiresAt Срок действия нового ключа
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// OverlapSeconds Сколько секунд прежний ключ еще принимается, не больше 30 дней. 0 - прежний ключ истекает сразу
+	OverlapSeconds *int64 `json:"overlap_seconds,omitempty"`
+}
+
+// APIKeyScope defines model for APIKeyScope.
+type APIKeyScope string
+
+// APIKeysResponse defines model for APIKeysResponse.
+type APIKeysResponse struct {
+	ApiKeys []APIKey `json:"api_keys"`
+}
+
+// AuditEvent defines model for AuditEvent.
+type AuditEvent struct {
+	// Actor `api_key`, `system` или `user:<id>`
+	Actor     string         `json:"actor"`
+	EventType AuditEventType `json:"event_type"`
+
+	// Hash sha256(prev_hash || запись) (hex), пустой - запись еще не связана в цепочку
+	Hash       string            `json:"hash"`
+	Id         int64             `json:"id"`
+	IpAddress  string            `json:"ip_address"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Outcome    AuditEventOutcome `json:"outcome"`
+
+	// PrevHash Хэш предыдущей записи (hex), пустой - запись еще не связана в цепочку
+	PrevHash  string              `json:"prev_hash"`
+	Reason    string              `json:"reason"`
+	SessionId *int64              `json:"session_id,omitempty"`
+	UserAgent string              `json:"user_agent"`
+	UserGuid  *openapi_types.UUID `json:"user_guid,omitempty"`
+	UserId    *int64              `json:"user_id,omitempty"`
+}
+
+// AuditEventOutcome defines model for AuditEvent.Outcome.
+type AuditEventOutcome string
+
+// AuditEventType defines model for AuditEventType.
+type AuditEventType string
+
+// AuditEventsResponse defines model for AuditEventsResponse.
+type AuditEventsResponse struct {
+	Events     []AuditEvent `json:"events"`
+	NextCursor *string      `json:"next_cursor,omitempty"`
+}
+
+// Device defines model for Device.
+type Device struct {
+	Browser string     `json:"browser"`
+	Os      string     `json:"os"`
+	Type    DeviceType `json:"type"`
+}
+
+// DeviceType defines model for Device.Type.
+type DeviceType string
+
 // ErrorResponse defines model for ErrorResponse.
 type ErrorResponse struct {
 	Reason string `json:"reason"`
 }
 
+// IntrospectionResponse defines model for IntrospectionResponse.
+type IntrospectionResponse struct {
+	Active    bool                            `json:"active"`
+	Exp       *int64                          `json:"exp,omitempty"`
+	Guid      *openapi_types.UUID             `json:"guid,omitempty"`
+	Iat       *int64                          `json:"iat,omitempty"`
+	Jti       *string                         `json:"jti,omitempty"`
+	Sub       *string                         `json:"sub,omitempty"`
+	TokenType *IntrospectionResponseTokenType `json:"token_type,omitempty"`
+}
+
+// IntrospectionResponseTokenType defines model for IntrospectionResponse.TokenType.
+type IntrospectionResponseTokenType string
+
+// JWK defines model for JWK.
+type JWK struct {
+	Alg string  `json:"alg"`
+	Crv *string `json:"crv,omitempty"`
+	E   *string `json:"e,omitempty"`
+	Kid string  `json:"kid"`
+	Kty JWKKty  `json:"kty"`
+	N   *string `json:"n,omitempty"`
+	Use string  `json:"use"`
+	X   *string `json:"x,omitempty"`
+	Y   *string `json:"y,omitempty"`
+}
+
+// JWKKty defines model for JWK.Kty.
+type JWKKty string
+
+// JWKSet defines model for JWKSet.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Session defines model for Session.
+type Session struct {
+	CreatedAt       time.Time  `json:"created_at"`
+	Current         bool       `json:"current"`
+	Device          Device     `json:"device"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	Id              int64      `json:"id"`
+	IpAddress       string     `json:"ip_address"`
+	LastRefreshedAt *time.Time `json:"last_refreshed_at,omitempty"`
+	UserAgent       string     `json:"user_agent"`
+}
+
+// SessionsResponse defines model for SessionsResponse.
+type SessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+}
+
+// TokenRequest defines model for TokenRequest.
+type TokenRequest struct {
+	Token         string                     `json:"token"`
+	TokenTypeHint *TokenRequestTokenTypeHint `json:"token_type_hint,omitempty"`
+}
+
+// TokenRequestTokenTypeHint defines model for TokenRequest.TokenTypeHint.
+type TokenRequestTokenTypeHint string
+
 // TokensResponse defines model for TokensResponse.
 type TokensResponse struct {
 	AccessToken string `json:"access_token"`
@@ -39,16 +283,201 @@ type UserGUIDResponse struct {
 	UserId openapi_types.UUID `json:"user_id"`
 }
 
+// WebhookDeliveriesResponse defines model for WebhookDeliveriesResponse.
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+// WebhookDelivery defines model for WebhookDelivery.
+type WebhookDelivery struct {
+	Attempt   int                `json:"attempt"`
+	CreatedAt time.Time          `json:"created_at"`
+	Error     *string            `json:"error,omitempty"`
+	EventId   openapi_types.UUID `json:"event_id"`
+	EventType string             `json:"event_type"`
+	Id        int64              `json:"id"`
+	LatencyMs int64              `json:"latency_ms"`
+
+	// ResponseSnippet Начало тела ответа получателя
+	ResponseSnippet *string `json:"response_snippet,omitempty"`
+
+	// StatusCode Нет, если ответ не получен (таймаут, ошибка соединения)
+	StatusCode *int `json:"status_code,omitempty"`
+	Success    bool `json:"success"`
+}
+
+// WebhookEndpoint defines model for WebhookEndpoint.
+type WebhookEndpoint struct {
+	CreatedAt   time.Time          `json:"created_at"`
+	Description string             `json:"description"`
+	Enabled     bool               `json:"enabled"`
+	Events      []WebhookEventType `json:"events"`
+
+	// Format Формат доставки: `json` - собственный конверт `{id, type, version, occurred_at, data}`,
+	// `cloudevents-structured` / `cloudevents-binary` - CloudEvents 1.0 в structured или binary HTTP-режиме
+	Format WebhookFormat `json:"format"`
+	Id     int64         `json:"id"`
+
+	// Secret Только в ответе на создание и на смену секрета
+	Secret    *string   `json:"secret,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Url       string    `json:"url"`
+}
+
+// WebhookEndpointCreateRequest defines model for WebhookEndpointCreateRequest.
+type WebhookEndpointCreateRequest struct {
+	Description *string            `json:"description,omitempty"`
+	Enabled     *bool              `json:"enabled,omitempty"`
+	Events      []WebhookEventType `json:"events"`
+
+	// Format Формат доставки: `json` - собственный конверт `{id, type, version, occurred_at, data}`,
+	// `cloudevents-structured` / `cloudevents-binary` - CloudEvents 1.0 в structured или binary HTTP-режиме
+	Format *WebhookFormat `json:"format,omitempty"`
+	Secret *string        `json:"secret,omitempty"`
+	Url    string         `json:"url"`
+}
+
+// WebhookEndpointUpdateRequest defines model for WebhookEndpointUpdateRequest.
+type WebhookEndpointUpdateRequest struct {
+	Description *string             `json:"description,omitempty"`
+	Enabled     *bool               `json:"enabled,omitempty"`
+	Events      *[]WebhookEventType `json:"events,omitempty"`
+
+	// Format Формат доставки: `json` - собственный конверт `{id, type, version, occurred_at, data}`,
+	// `cloudevents-structured` / `cloudevents-binary` - CloudEvents 1.0 в structured или binary HTTP-режиме
+	Format       *WebhookFormat `json:"format,omitempty"`
+	RotateSecret *bool          `json:"rotate_secret,omitempty"`
+	Url          *string        `json:"url,omitempty"`
+}
+
+// WebhookEndpointsResponse defines model for WebhookEndpointsResponse.
+type WebhookEndpointsResponse struct {
+	Endpoints []WebhookEndpoint `json:"endpoints"`
+}
+
+// WebhookEventType Тип события безопасности, `*` - все типы
+type WebhookEventType string
+
+// WebhookFormat Формат доставки: `json` - собственный конверт `{id, type, version, occurred_at, data}`,
+// `cloudevents-structured` / `cloudevents-binary` - CloudEvents 1.0 в structured или binary HTTP-режиме
+type WebhookFormat string
+
+// AuditEventTypeFilter defines model for AuditEventTypeFilter.
+type AuditEventTypeFilter = AuditEventType
+
+// AuditFrom defines model for AuditFrom.
+type AuditFrom = time.Time
+
+// AuditIP defines model for AuditIP.
+type AuditIP = string
+
+// AuditTo defines model for AuditTo.
+type AuditTo = time.Time
+
+// AuditUserGUID defines model for AuditUserGUID.
+type AuditUserGUID = openapi_types.UUID
+
+// ListAuditEventsParams defines parameters for ListAuditEvents.
+type ListAuditEventsParams struct {
+	UserGuid  *AuditUserGUID        `form:"user_guid,omitempty" json:"user_guid,omitempty"`
+	EventType *AuditEventTypeFilter `form:"event_type,omitempty" json:"event_type,omitempty"`
+
+	// From Начало периода (включительно)
+	From *AuditFrom `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Конец периода (не включительно)
+	To     *AuditTo `form:"to,omitempty" json:"to,omitempty"`
+	Ip     *AuditIP `form:"ip,omitempty" json:"ip,omitempty"`
+	Cursor *string  `form:"cursor,omitempty" json:"cursor,omitempty"`
+	Limit  *int     `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// ExportAuditEventsParams defines parameters for ExportAuditEvents.
+type ExportAuditEventsParams struct {
+	UserGuid  *AuditUserGUID        `form:"user_guid,omitempty" json:"user_guid,omitempty"`
+	EventType *AuditEventTypeFilter `form:"event_type,omitempty" json:"event_type,omitempty"`
+
+	// From Начало периода (включительно)
+	From *AuditFrom `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Конец периода (не включительно)
+	To     *AuditTo                       `form:"to,omitempty" json:"to,omitempty"`
+	Ip     *AuditIP                       `form:"ip,omitempty" json:"ip,omitempty"`
+	Format *ExportAuditEventsParamsFormat `form:"format,omitempty" json:"format,omitempty"`
+}
+
+// ExportAuditEventsParamsFormat defines parameters for ExportAuditEvents.
+type ExportAuditEventsParamsFormat string
+
 // IssueTokensParams defines parameters for IssueTokens.
 type IssueTokensParams struct {
 	Guid openapi_types.UUID `form:"guid" json:"guid"`
 }
 
+// ListWebhookDeliveriesParams defines parameters for ListWebhookDeliveries.
+type ListWebhookDeliveriesParams struct {
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// CreateAPIKeyJSONRequestBody defines body for CreateAPIKey for application/json ContentType.
+type CreateAPIKeyJSONRequestBody = APIKeyCreateRequest
+
+// RotateAPIKeyJSONRequestBody defines body for RotateAPIKey for application/json ContentType.
+type RotateAPIKeyJSONRequestBody = APIKeyRotateRequest
+
+// IntrospectTokenFormdataRequestBody defines body for IntrospectToken for application/x-www-form-urlencoded ContentType.
+type IntrospectTokenFormdataRequestBody = TokenRequest
+
+// RevokeTokenFormdataRequestBody defines body for RevokeToken for application/x-www-form-urlencoded ContentType.
+type RevokeTokenFormdataRequestBody = TokenRequest
+
+// CreateWebhookEndpointJSONRequestBody defines body for CreateWebhookEndpoint for application/json ContentType.
+type CreateWebhookEndpointJSONRequestBody = WebhookEndpointCreateRequest
+
+// UpdateWebhookEndpointJSONRequestBody defines body for UpdateWebhookEndpoint for application/json ContentType.
+type UpdateWebhookEndpointJSONRequestBody = WebhookEndpointUpdateRequest
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
-	// Деавторизация пользователя
+	// Публичные ключи для проверки access-токенов
+	// (GET /.well-known/jwks.json)
+	GetJWKS(ctx echo.Context) error
+	// Список API-ключей
+	// (GET /api-keys)
+	ListAPIKeys(ctx echo.Context) error
+	// Выпустить API-ключ
+	// (POST /api-keys)
+	CreateAPIKey(ctx echo.Context) error
+	// Отозвать API-ключ
+	// (DELETE /api-keys/{key_id})
+	RevokeAPIKey(ctx echo.Context, keyId int64) error
+	// Ротировать API-ключ
+	// (POST /api-keys/{key_id}/rotate)
+	RotateAPIKey(ctx echo.Context, keyId int64) error
+	// Журнал аудита
+	// (GET /audit/events)
+	ListAuditEvents(ctx echo.Context, params ListAuditEventsParams) error
+	// Выгрузка журнала аудита
+	// (GET /audit/events/export)
+	ExportAuditEvents(ctx echo.Context, params ExportAuditEventsParams) error
+	// Проверить активность токена (RFC 7662)
+	// (POST /auth/introspect)
+	IntrospectToken(ctx echo.Context) error
+	// Деавторизация пользователя на текущем устройстве
 	// (POST /auth/logout)
 	Logout(ctx echo.Context) error
+	// Деавторизация пользователя на всех устройствах
+	// (POST /auth/logout/all)
+	LogoutAll(ctx echo.Context) error
+	// Отозвать токен (RFC 7009)
+	// (POST /auth/revoke)
+	RevokeToken(ctx echo.Context) error
+	// Список активных сессий текущего пользователя
+	// (GET /auth/sessions)
+	ListSessions(ctx echo.Context) error
+	// Завершить сессию на одном устройстве
+	// (DELETE /auth/sessions/{session_id})
+	RevokeSession(ctx echo.Context, sessionId int64) error
 	// Выдать новую пару токенов для пользователя
 	// (POST /auth/tokens)
 	IssueTokens(ctx echo.Context, params IssueTokensParams) error
@@ -58,6 +487,27 @@ type ServerInterface interface {
 	// Получить GUID текущего пользователя
 	// (GET /auth/user/guid)
 	GetUserGUID(ctx echo.Context) error
+	// Список получателей webhook-событий
+	// (GET /webhooks/endpoints)
+	ListWebhookEndpoints(ctx echo.Context) error
+	// Добавить получателя webhook-событий
+	// (POST /webhooks/endpoints)
+	CreateWebhookEndpoint(ctx echo.Context) error
+	// Удалить получателя webhook-событий
+	// (DELETE /webhooks/endpoints/{endpoint_id})
+	DeleteWebhookEndpoint(ctx echo.Context, endpointId int64) error
+	// Получатель webhook-событий
+	// (GET /webhooks/endpoints/{endpoint_id})
+	GetWebhookEndpoint(ctx echo.Context, endpointId int64) error
+	// Изменить получателя webhook-событий
+	// (PATCH /webhooks/endpoints/{endpoint_id})
+	UpdateWebhookEndpoint(ctx echo.Context, endpointId int64) error
+	// История доставок получателю
+	// (GET /webhooks/endpoints/{endpoint_id}/deliveries)
+	ListWebhookDeliveries(ctx echo.Context, endpointId int64, params ListWebhookDeliveriesParams) error
+	// Отправить тестовое событие
+	// (POST /webhooks/endpoints/{endpoint_id}/test)
+	SendTestWebhook(ctx echo.Context, endpointId int64) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -65,6 +515,201 @@ type ServerInterfaceWrapper struct {
 	Handler ServerInterface
 }
 
+// GetJWKS converts echo context to params.
+func (w *ServerInterfaceWrapper) GetJWKS(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetJWKS(ctx)
+	return err
+}
+
+// ListAPIKeys converts echo context to params.
+func (w *ServerInterfaceWrapper) ListAPIKeys(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"api_keys:admin"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListAPIKeys(ctx)
+	return err
+}
+
+// CreateAPIKey converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateAPIKey(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"api_keys:admin"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateAPIKey(ctx)
+	return err
+}
+
+// RevokeAPIKey converts echo context to params.
+func (w *ServerInterfaceWrapper) RevokeAPIKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "key_id" -------------
+	var keyId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "key_id", ctx.Param("key_id"), &keyId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter key_id: %s", err))
+	}
+
+	ctx.Set(ApiKeyAuthScopes, []string{"api_keys:admin"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RevokeAPIKey(ctx, keyId)
+	return err
+}
+
+// RotateAPIKey converts echo context to params.
+func (w *ServerInterfaceWrapper) RotateAPIKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "key_id" -------------
+	var keyId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "key_id", ctx.Param("key_id"), &keyId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter key_id: %s", err))
+	}
+
+	ctx.Set(ApiKeyAuthScopes, []string{"api_keys:admin"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RotateAPIKey(ctx, keyId)
+	return err
+}
+
+// ListAuditEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) ListAuditEvents(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"audit:read"})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListAuditEventsParams
+	// ------------- Optional query parameter "user_guid" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "user_guid", ctx.QueryParams(), &params.UserGuid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_guid: %s", err))
+	}
+
+	// ------------- Optional query parameter "event_type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "event_type", ctx.QueryParams(), &params.EventType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter event_type: %s", err))
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", ctx.QueryParams(), &params.From)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter from: %s", err))
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", ctx.QueryParams(), &params.To)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter to: %s", err))
+	}
+
+	// ------------- Optional query parameter "ip" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "ip", ctx.QueryParams(), &params.Ip)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter ip: %s", err))
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", ctx.QueryParams(), &params.Cursor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter cursor: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListAuditEvents(ctx, params)
+	return err
+}
+
+// ExportAuditEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) ExportAuditEvents(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"audit:read"})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ExportAuditEventsParams
+	// ------------- Optional query parameter "user_guid" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "user_guid", ctx.QueryParams(), &params.UserGuid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_guid: %s", err))
+	}
+
+	// ------------- Optional query parameter "event_type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "event_type", ctx.QueryParams(), &params.EventType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter event_type: %s", err))
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", ctx.QueryParams(), &params.From)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter from: %s", err))
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", ctx.QueryParams(), &params.To)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter to: %s", err))
+	}
+
+	// ------------- Optional query parameter "ip" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "ip", ctx.QueryParams(), &params.Ip)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter ip: %s", err))
+	}
+
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", ctx.QueryParams(), &params.Format)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter format: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ExportAuditEvents(ctx, params)
+	return err
+}
+
+// IntrospectToken converts echo context to params.
+func (w *ServerInterfaceWrapper) IntrospectToken(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"tokens:introspect"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.IntrospectToken(ctx)
+	return err
+}
+
 // Logout converts echo context to params.
 func (w *ServerInterfaceWrapper) Logout(ctx echo.Context) error {
 	var err error
@@ -76,11 +721,62 @@ func (w *ServerInterfaceWrapper) Logout(ctx echo.Context) error {
 	return err
 }
 
+// LogoutAll converts echo context to params.
+func (w *ServerInterfaceWrapper) LogoutAll(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.LogoutAll(ctx)
+	return err
+}
+
+// RevokeToken converts echo context to params.
+func (w *ServerInterfaceWrapper) RevokeToken(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"tokens:revoke"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RevokeToken(ctx)
+	return err
+}
+
+// ListSessions converts echo context to params.
+func (w *ServerInterfaceWrapper) ListSessions(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListSessions(ctx)
+	return err
+}
+
+// RevokeSession converts echo context to params.
+func (w *ServerInterfaceWrapper) RevokeSession(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "session_id" -------------
+	var sessionId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "session_id", ctx.Param("session_id"), &sessionId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter session_id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RevokeSession(ctx, sessionId)
+	return err
+}
+
 // IssueTokens converts echo context to params.
 func (w *ServerInterfaceWrapper) IssueTokens(ctx echo.Context) error {
 	var err error
 
-	ctx.Set(ApiKeyAuthScopes, []string{})
+	ctx.Set(ApiKeyAuthScopes, []string{"tokens:issue"})
 
 	// Parameter object where we will unmarshal all parameters from the context
 	var params IssueTokensParams
@@ -118,6 +814,127 @@ func (w *ServerInterfaceWrapper) GetUserGUID(ctx echo.Context) error {
 	return err
 }
 
+// ListWebhookEndpoints converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWebhookEndpoints(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"webhooks:read"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWebhookEndpoints(ctx)
+	return err
+}
+
+// CreateWebhookEndpoint converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateWebhookEndpoint(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"webhooks:write"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateWebhookEndpoint(ctx)
+	return err
+}
+
+// DeleteWebhookEndpoint converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteWebhookEndpoint(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "endpoint_id" -------------
+	var endpointId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "endpoint_id", ctx.Param("endpoint_id"), &endpointId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpoint_id: %s", err))
+	}
+
+	ctx.Set(ApiKeyAuthScopes, []string{"webhooks:write"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteWebhookEndpoint(ctx, endpointId)
+	return err
+}
+
+// GetWebhookEndpoint converts echo context to params.
+func (w *ServerInterfaceWrapper) GetWebhookEndpoint(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "endpoint_id" -------------
+	var endpointId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "endpoint_id", ctx.Param("endpoint_id"), &endpointId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpoint_id: %s", err))
+	}
+
+	ctx.Set(ApiKeyAuthScopes, []string{"webhooks:read"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetWebhookEndpoint(ctx, endpointId)
+	return err
+}
+
+// UpdateWebhookEndpoint converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateWebhookEndpoint(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "endpoint_id" -------------
+	var endpointId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "endpoint_id", ctx.Param("endpoint_id"), &endpointId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpoint_id: %s", err))
+	}
+
+	ctx.Set(ApiKeyAuthScopes, []string{"webhooks:write"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UpdateWebhookEndpoint(ctx, endpointId)
+	return err
+}
+
+// ListWebhookDeliveries converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWebhookDeliveries(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "endpoint_id" -------------
+	var endpointId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "endpoint_id", ctx.Param("endpoint_id"), &endpointId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpoint_id: %s", err))
+	}
+
+	ctx.Set(ApiKeyAuthScopes, []string{"webhooks:read"})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListWebhookDeliveriesParams
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWebhookDeliveries(ctx, endpointId, params)
+	return err
+}
+
+// SendTestWebhook converts echo context to params.
+func (w *ServerInterfaceWrapper) SendTestWebhook(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "endpoint_id" -------------
+	var endpointId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "endpoint_id", ctx.Param("endpoint_id"), &endpointId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpoint_id: %s", err))
+	}
+
+	ctx.Set(ApiKeyAuthScopes, []string{"webhooks:write"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.SendTestWebhook(ctx, endpointId)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -146,35 +963,132 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 		Handler: si,
 	}
 
+	router.GET(baseURL+"/.well-known/jwks.json", wrapper.GetJWKS)
+	router.GET(baseURL+"/api-keys", wrapper.ListAPIKeys)
+	router.POST(baseURL+"/api-keys", wrapper.CreateAPIKey)
+	router.DELETE(baseURL+"/api-keys/:key_id", wrapper.RevokeAPIKey)
+	router.POST(baseURL+"/api-keys/:key_id/rotate", wrapper.RotateAPIKey)
+	router.GET(baseURL+"/audit/events", wrapper.ListAuditEvents)
+	router.GET(baseURL+"/audit/events/export", wrapper.ExportAuditEvents)
+	router.POST(baseURL+"/auth/introspect", wrapper.IntrospectToken)
 	router.POST(baseURL+"/auth/logout", wrapper.Logout)
+	router.POST(baseURL+"/auth/logout/all", wrapper.LogoutAll)
+	router.POST(baseURL+"/auth/revoke", wrapper.RevokeToken)
+	router.GET(baseURL+"/auth/sessions", wrapper.ListSessions)
+	router.DELETE(baseURL+"/auth/sessions/:session_id", wrapper.RevokeSession)
 	router.POST(baseURL+"/auth/tokens", wrapper.IssueTokens)
 	router.POST(baseURL+"/auth/tokens/refresh", wrapper.RefreshTokens)
 	router.GET(baseURL+"/auth/user/guid", wrapper.GetUserGUID)
+	router.GET(baseURL+"/webhooks/endpoints", wrapper.ListWebhookEndpoints)
+	router.POST(baseURL+"/webhooks/endpoints", wrapper.CreateWebhookEndpoint)
+	router.DELETE(baseURL+"/webhooks/endpoints/:endpoint_id", wrapper.DeleteWebhookEndpoint)
+	router.GET(baseURL+"/webhooks/endpoints/:endpoint_id", wrapper.GetWebhookEndpoint)
+	router.PATCH(baseURL+"/webhooks/endpoints/:endpoint_id", wrapper.UpdateWebhookEndpoint)
+	router.GET(baseURL+"/webhooks/endpoints/:endpoint_id/deliveries", wrapper.ListWebhookDeliveries)
+	router.POST(baseURL+"/webhooks/endpoints/:endpoint_id/test", wrapper.SendTestWebhook)
 
 }
 
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/8xWzW7bxhN/lcX+/wcHoEW5SS+8uWgbqOnByAdawDUChlpbTCyS2V0aUA0B+ijqBA5i",
-	"wOi1MdC+AK2GsCJH8ivMvFExS9KyTUmugdbwjdLO7uzM72N2l3thMwoDEWjFnV2uvIZouubzGylD+Vio",
-	"KAyUoD8iGUZCal+YZSlcFQb0pVuR4A5XWvrBFm+3LS7F69iXos6d9SJuwyriwhcvhad52+JPw1ciUPNT",
-	"uJ4nlHquKez6RJeiZ6V7poR8+Kz29fyEsRLyuV+nz81QNl3NHR7Hfp1b1+QuNpbTti2uhBdLX7eeUG+z",
-	"RKuR/0i0VmPdoF9+wB3eEG5dSG7xwG3SAT8ur67Vlh+J1jS5a3ZRKV8JVwpZ7H9hfn1b3Pi7H55yK0OS",
-	"dmWr01MaWke8TRfzg82Q9teF8qQfaZ/w5KtrNQYf4RQPGCTYxx6kMMYeDPEXGMIIEvwVhjBkcAYTOMV3",
-	"cAITGEBiAk8hhU9sCQa4Dx8hwT1ILAYTOIaxiaL1MQwhpX+xBye4DwOGPZjAyCxNYGAVR/dxrwhnBNy9",
-	"yk8B1eHrbSqEymdPhNzxPcFW12rc4jtCqqyKlUq1UqVWhZEI3MjnDr9fqVbuc4tHrm4YFGw31g17O9wK",
-	"Y23IECpd7gf8SZVQPyDFHoMBdiFlUmxKoRrL9AO72F3QEjxgS3OLzWsiFrqUsFbnDv8+uxJxLKOque4X",
-	"1QezboddOIMU39B5VPCD6gqFeWGgRWDqcaNo2/fM8fbLXLSZ0Onr/1Jscof/z546gZ3bgH3ZAwxprqT/",
-	"HVJIYEBFYQeGReUwvkR97qzvXiLt+kZ7w+IqbjZd2aJzfiudY3hGJJzTVJMgg9BoXi2A8BAmcAID7ECC",
-	"byHJgDT9xz6+pxQJdrDPMg+xc3CvQHWuinkoY5dhnxRCt4cxjHEfPhvmVhj8gR1I4Rj7JrkR2QhO8T3u",
-	"zWJATalYZP5oGCvdptBCKtNI4xevYyFbU7vYymxqakpaxsK6APN1hrZRYlv1X6PRFaOfxaMjQgCSUstz",
-	"I4ExJBm5q7dM7hER0mA3wp6B9BMz+J5hBybYvX3JfcA3MIRjItpih16CMaQwgBQ7+cUv0u5eWaEXx1JJ",
-	"oYe5pffw3Uzt3EwsJfUWolug4g/TMZKb8Vy94n6FwZFxEpofn3PNn7v2eSAjr2E0E5fDYLvFvDB85QuL",
-	"QZIfXoo14P9FVZmrEArUsVD6Pxu8Z8n5cZb3XNB3UWlXh3Ryd5l9w9ky5c0wY+9syl6gJL3nbGOpzi7f",
-	"Ev90pJDVzx8PtFAmFfbZUnb7mU+Bh0IX79b/kjelt/EMeBZWd6deHhb/8lbnxCHBiD0zJWjsHxDakwtk",
-	"Nq/ETm7FyQ3pe3T+GM7oa3AwfR9hH99CSm60yGhNNrlTPB9iuc0dbruRb++s8PZG++8AAAD//zvENQUF",
-	"DgAA",
+	"H4sIAAAAAAAC/+w9+3PbRnr/yg7amVoZ8CEnzs3xftL5kVOc1h5Jqa9je0iYXEuwSIAHgLJUn2b0qOOk",
+	"9lntTdrc3DTxJdf+TitmTEsi9S/s/ked79sFsAAWfNiW4lz0iyMCi91vv9d+z80Do+622q5DncA3Kg+M",
+	"tuVZLRpQD3/NdRp2cHmNOsHSRptesZsB9eC57RgV43cd6m0YpuFYLWpUDArDqsFGmxqm4ddXaMuCoX/v",
+	"0btGxfi7UrxOSbz1S8npjc1NU6x4xXNb8G2D+nXPbge2C+uxr1mXP2JddsiGhB2zHt9ifTZkL1iXnGP7",
+	"7IAd8qf8EevzHdZjh/wJG7DhjGFqob0LS6hw3nW9lhUYFaNhBbQQ2C3YBu6mYviBZzvLMXzz1/OQYLcT",
+	"k+Z8v+RqdvdnNmQD1uOfZfc2YD0y7QYD97W396lPvY8+nb+Ut8mOT73qcsdu6FfoiDfpyTfDwYKzrs9f",
+	"pRvwV9tz29QLbIrP6x61AtqoWkFizhFQmwZdb9se9af6xm4kxtpO8OEH8TjbCegy9WBg0/KDasefEiSB",
+	"qQfZF22P3rXXNeT/hu+wA77FH/Md1uV7BDidb/Md/oREhO+SmrVavdUpl9+vi4nwb1otFos1E/hmSIBZ",
+	"2KvoG8J32Us2YF3W4zt8m+8Rtk9Agtj3rMsf6mD36Jq7OuV+/brbFgS0A9ryxwo+Un8RPjI2o+ksz7M2",
+	"kA09+ruO7dGGUblpIDMhPiPsReuZKr/cjiZy79yj9QBmFgtdxEEL9Hcd6gdZnnsd/gkJ3LLWP6HOcrBi",
+	"VGbLZdNo2U70+63jqWU78+Kz2TFIk/iSy43DDMqC1Wxeu2tUbk4CkbFpppG4Sjc0XP0t67KjiBnNULX1",
+	"2IskR9Z+W5i7Pl+4SjdqRcKesSE7YF32kj9m++pAUIh9NiB8C95qNZiKBYApu/fb0e4X3GByvkjvjG8B",
+	"lIS9AHkDSWX7rA9ADtiQ7aOADRXRNcwJOctdo17Tald9Wnedhq9F6gEbov4/YEPCt1mPHfBdNmAvCDtG",
+	"7P7ABqyvagHW41+wnnjdx5dHMV5NIk6Y52JW/jnrkffLsDPUJUVSJoX8mfu49x4SrMd3CN8W1OG7hplV",
+	"sC1r3W51Wkbl/IVfni9LiRFPyln1u5nLuEImgEgOfHvTeA9w6a5Sx6/Yvt+h8U+hz5TXTuC5fhumM437",
+	"9M6K667CKKuh/r7v2QF8ZMGJGL612nZ1lW74FavRsh3jdpp8prFeAIAKa5YHIugDZArAc82mkdjBEoI0",
+	"LwHOvFgIQc9+om5CeXtDwr8gANa8uSE3poIFe8x+MSc2GeHcX6B+23V8mhWVEDFTaraxyj+aV6vCIvNR",
+	"A1A9cL2s7NTkhDWT1PwNP6CtGmF9dsj6pAZmTUUcrnYD/0trWnMjNnSntG9NY8XyV7JQ+SvW+Qsfnmt7",
+	"dK0KI8jvf0/YS9ZlxyhdT2bIuRW6PgPqk++iuA3ZK1JIjIlEHESZb7N9voevB6wLCpZ/xnpgH/BHoCve",
+	"yCKy21Wr0fCo72stHLde73jelPaD2wnqbish0H6nXoc1TOOuZTc7Hp1U3ADp18R8i9Ec6tMr4XzCHhM4",
+	"z1KF/R//A/88VHwv+GP2gu8CjkH9RZhn/dMgjkct33W0+Pap79uuU52Yfmi+W8tSbDLzxdb9eKNejp5w",
+	"aZ1hp3KLmfQhhQgn+C0BfMw1EX5UekphG604ljaSpwgeEUU8QBrhiVH06F2P+iuJJ2giGxH2lSdNd9nt",
+	"BNEfRavZVL7r+LTaoAGtB7SR2E6xZfstK6ivTMPmeBbMh9DGjxYUiNWnIYz4bFGAnnr6SQi+8kseW8pE",
+	"HZ9eincReY1zsJN/jDaSQPWI0wPJPsXZEc2ZPT9Mw6HrQbXe8Xyh/0dbiHJlHZNcomt2XQPsHc+971NP",
+	"r/v0KjFIcVmD+quB2wZzyL1jN1EjWneaFNB+x4V/3WCFellOSIMfwoIry8G6vVz2PNfLx3+udkktJ8fp",
+	"VojtEWSpXDuhHthrqmd8x3Wb1HKkGz+hBptYO9lWMOGU9wJbr107d/QkBTmopglr4XFTxZeolVAM5e+x",
+	"1JTI0aH34xtXNchsLmthq3tr2uf6iMSq3dA/DzbUrS0szhmmcfmiYRrXrl7XbMY0nLzzRPt8Xft0YzwX",
+	"AmACbDG5iYjIwdoi1RiHU1mqgPpxZmquiSp17NuJc+FJ6QR66WlE6mrUZqRSO+mg2RgTEWNq0ak6FQgj",
+	"jRedgZFvPUiMJWJICbzEGB9B2hHHmrQNJmc1OeVYdosm1sElT+icmIZQRZUH4wJVsX6rrthOoGqCN1Ny",
+	"6WEpwEf5mOq6Y4mfGK1bLoxy5y+os21zgtvJtcMPdctKN/wSbdpr1LPpiA03ojETc1By9vH+tbLEeGA1",
+	"4XorCGirrcqiogVeK5YPlor+9ELfYMKDP+mjv0n0P6BOfaPa8if8wJPUrPqO3W7TYEwaS2RywAscYgCx",
+	"B9F/DOSzQ74Lw8QIvqfbpR9YQcev1t0G1a7T4zsmuJ7bGOCIl5DxvniVHhuQc7A0ewWBQb6LHw7556zP",
+	"nkNkD5zWIYZtIXrYgyAg35vRYiD02zWnlE4/R2RNe3+Ss0wlEKBQY2zkX7LuZafRdm1dhOh1uDOBYR2P",
+	"OmDGN3Ls2+m8nHADavgo7euEUE800xUxeBr292nd0/Lwd0oAmu0rrCWCHJJfXmKYHwLGPcL60YsjYCC+",
+	"G0autwTXa0/7dmNqGnW85oSmAYxM0jSmYITbiG4pO0EBbQL2G5OBmoKvGvSu1WkGRiXwOtQ8GTYbkWd6",
+	"bZ6LWUm1PD7MJ+BIAyV94CIlRzjzKXJ82m68JXL8ZPDvYa6rGpMhC/mEiB+H3FGBnnDI1OiRH441aOIV",
+	"RrGBGvrLaLY+Oxbq6zkm4zGn95z12Es2ZMeQlIcMHwZ6+yapvVeDWO8+6DI4zfvsmD82zMhYfk+JEUr9",
+	"kYgaxnG6bCRxipgh+DnF+orlLKsxyAkjiRjdk77HxQjGKDwYg5iJGGqjgdlAoGnMty9GwMkI4+2YHlci",
+	"lk4R43/ZkG+hRbID6UiB9S6WwvQrpHbPdx1Av6SWyMGCcTLgj0WOcsgG8Ihv8R1Se2A3TAIIMcka9WAn",
+	"JlHizyZpWIG1WTNvObV60+00hCQX/MDr1IOORxs1UiKJV3dsx/I2AISL8FTEOclssQyHYvxdmGISw8lv",
+	"lpauF2Q2FRKxvVuOwjH3RCBbD0HqhZhQ73T5tN7x7GBjESRJiN9c275KN+Y6wYq2/kgWjGwDvjCZvc26",
+	"BUj0wgHOBoD6ImHfyBx+l3+GssF3cCvP+S6allhyYELZCWbwWTcmxz4JYfrVLScuUNlmQ/EVuWW8d8sg",
+	"Aiesp9Cc77JjIo0NgA9qCoZJONhR8ZYTbUIILAEzA1PWIhsvVlGta1zlg/L7RSQBFjutUKuBIVVZ7RRV",
+	"JsTGhoV4BIXya2p51Asxegd/hdxsfHxjKayQQk2Lb+NZVoKgLeqibOeupiJs7vo8IOAQ9A+a5JIIff5v",
+	"rM8OxMZZP9zPE1RQ+6HHgGmqc2wf0lbC4QCLnj2X5QmHwohnPVMYb1hqQTB5dYCvhmzfTLkIMJyAyzwj",
+	"0BXYQRM2Atsni9SDcAqZuz5vmIaUL6NizBbLxTLGxtvUsdq2UTHeL5aL7xum0baCFeTLUvE+bTYLq457",
+	"3yndu7/qF+/JePSy1vr8I9qW+0j8LyQRP75xlSzSgJxbuHKR/OLC7C9mCN/GnBx7DhzMHyEbHgHCoqIM",
+	"+GkiY8HOofpKDDjGShORvMMSFP5UlqCIsEIhRhR/nOA7wPdz1udbAiz8RtRlgSh8D+hE/B/wXVJbtRs1",
+	"FecgXl8iwX+zeGH2PBrMUJXBtzLJRdB3X2AlhmpFh45duGlYJgQDwPwONkrYD6yXmFuRMnQFxSjAyhYb",
+	"8L1YJcC/3QqpfXR5ieiJVhOsAYe+BeSabxgV4yMaQCjWiN1jJPv5chn+U3edQMbzrHa7adfxw1LIApOV",
+	"kspQL4pTklskYwid2Gm1QGFWDPYsxRi9mC36kdgdY5GP2PcB62epD29x5pLVtgthXFnPtX+OpufbiiaD",
+	"5Y6ECyTdJcmnplrzCSWBgkuB86Nhwq+KSnDQYe8Viai7Ulm9L3PPD5EzB3wv5E35gu0nZSpkeB05P7H9",
+	"QFaEnCRJ00UnGtqCZo63CFrmg/LsWwMgmTXTLM++USIkI3U01vBKRhKnIWp3CfpM4sDGGjz1qL6ZqTva",
+	"vJ3g5W+FqsJ6tARKeuwVIKXt+iMYMkP7SHHxHXmySDef/wGfHCX9fWF/hZwlqpMJ64GyAxYm7D/ZlySy",
+	"3bRz84dQaqHjNWGPClYwhKlP/eDXbmPjLfNZ0kPfTPoV4GtvZlh99kRAaGg5LXHAHMs6lIHg+PIpcvzX",
+	"rCcOBjT6DvhOaNxhtQuoS779s5XDP0raoG+IpduqNCbPidKDVbpRtRubMtJPAzpKRGXdbCxEqVpOWE3I",
+	"VVR+WSTsK7UESalG7WXlEcR7WwyGow2Mp1pcCa491oUfqMhmQjo+GLWdxEn2U+YXgPyDU4Q8wuAgtOFe",
+	"YQXyIPQx0eHpZTD8Rnz9TTyXhqvNRNPQTdkzAsZ97EQJXjfSOlXbP5JbPnZbKz8lEdmCKXLOuUgsoxpl",
+	"xfkUKZgjaRQL13MgnvSFw/gPwpaCuvScEmhtVXVY81dLFXTXbjnn0CPgu+wIz8BH8tx8Smpz1+erVy//",
+	"S3Xh2tLc0vy1f6pe++fLC5/MXa/NmIQ/QjI854+J6pQT3BkY7oeRmmCvgJdFwF0YqcC7JmrpcL+pum1w",
+	"D74ORyp7eyPbQKs1kFyR1jgt3jlJ2yHZR7ApjYcfzVbQkvHMbPhZHANmSvOHx0Is7m94FvwF5+9Lx7ib",
+	"Z+VAiWYpToXoPeKvlCJq9gPfBexiYlyQA7LNYKOYsQ7rhSpuX/jI6OoeYnn2Ln+KjiuaQDuRM/IZ68Zc",
+	"1kWCqy1FYOaIYlFsBHhJakr9aO2WAyfqTuimwxGBnAFdMmKJI9b/VfIbpIkIxYhMPqbfe2E3TRq6Xq6H",
+	"HVfOZtWkjnviIaVkG+emOdkH6UbfSb/Ddt1JBy+5Ew+dv45DdS2oAteje231Xzbtlh0kPowyqqKBL2xQ",
+	"upBoTprNO1NOKvihqZvWKYJv06yeEKN3R9GrYhPppAOAFYMHW3/DPmPcQJbWpf8d0yqh8LI6tETX264X",
+	"5KvSZ1LtH0i1vCeO/O/5FjYA4yYx4Mgfqs0rPbQKMdzNH+K/exBW5g/lwwTd+GMRzzlG+u6xF8JRVJpX",
+	"AFvCr+RbGm0NeQNM2jUxaYphcTBo/12wCoTEY76vhfUisDTsjO+apFb310TGLxNQB+PTlJEkYK59skLX",
+	"dcr1MqLyTL2OUK9R7YtGS2KCsplKWMLvur+my0ROpyXXC04jK+maIgS6HpRgxZHjNk2dL5gQip+AvvxZ",
+	"6sbRhNKqy2ClpPT25scDRIotkecW2U41Dxg26+1icuYp+NkYVwuze2j4fnxjqUjm0kkhmTcSChVAJCIk",
+	"/kPYga2klEK3ngiV1bIbjSa9b3lURgmiNOQTU4T2sOcc6gbgVoe+SRrU2WjafjBjEllNkoCkz7+IbF1U",
+	"uD5tUmisIyLd25VJsM9EYyIEGLchuiHCAT20lSHU8EKQ1wz3hqDItLAgfHioZ5NUr5QcJynISAGpiXaX",
+	"CrlrNX0K11d0BcrVulOd/o5bjZaiEvRJPPv1wv379wug2godr0kdKJttTC41ibr6ifIDb0+P6LurdJL7",
+	"F6h74LtCdYi6mRTHnTn/p6vjsrcOpFXdM4VAIm3AuogmcFVlrRl/kigUkGUOH354fkbRfrLsK1/zfRNW",
+	"esg4qJVVXRhBhjz0Id/DMclAH6b1Yk0TaQz+FEqDZE4BRovsdDwgt1BFJLbxw6jKQt2/dPR1XnLYqjpB",
+	"5uGvIkbKPweEnj6zgQh02b44XVg/xIAuMq+WFN1Ms8qXmXnCMqx85KIVLcoDRPzviIgcFbJdeHVJL8NH",
+	"Jehcfru8JOsks+wzgjvyKS8KF8+IP474keOnITvcARUTXl6Vkk/0Z+lMR0j28PjX2iBKiZn8SqdUVGVS",
+	"0bHTsSjADe8OAgsmtH7MW45GJ+2l+A8LWsISKzZImjfhjT0j0mjRoIQqxh8/aGpoIsPrfLmcnz/9KRgx",
+	"mu6Pg6hSLJ3IPTMtTtm0kFI7LmuriJKwHsrlX6rWg9quOnndpU6nmFLvKP5UGFHKKqkngljiSjrZXYZh",
+	"fUWK1dML5C+jHMKmOfDVHskHNdm9WyHA2bW8OHvYx3uSpWyZXmEd1/xHwuZJ2k4/3UNLrU1LmnX8obrF",
+	"V1kq551qGp4tPYivwRldUfPXhEGiM2QnBwRfkPlL2iOO9Vgvy6j554DkkYlS4vFm30ZafKz9pMoiCqto",
+	"qfhcCNo7xZunnYhNoCadjGXdKYXlKwW3whNMMKbQc0NprYw148XxMKowRqPPZap1F5Y7xjj+ruTikhSW",
+	"VJdAXCydIyR8O9sNcoR9BEXCvks0j6innzb0A1cdiXsCcuQkFche7kwqIjmN/SeZ40tdeKBjr2dAAdbN",
+	"oFy2dcTid2Z0nXY8B1hRG7V+Ie0tnSRNJzoZWQ5FcJRbHrf4yDMuV3r5Y1nTlvTotC4ce0mgX6ngOs0N",
+	"UnfdVZtixFbn9bOXghXU5FyXAP5cz/5XpL7+GMR1I/F+F+Uu3UDVfXf5fMqjJ+YbGYLUs6zCktCHWgqv",
+	"4prcYQDFP8aiyjZZ7ZJzAvqZnP6iKBt7gnyTuTFGQ56Ru3vHLKULp3pq/BHIKJtFB9iBtJe5YkTpMpuS",
+	"fZ9FjYqCfZEOU3oU4d2/pUSbuuTsrOOYbns/SdbLbbHXK6/U7TF/y61RyQucR3qfmVt1sB5PTlBQW/5H",
+	"tk39l7xPpybuUqhFt+nEN5tjGSachd+LanJM7GwpvZgY34i7Nictsh5/zwpWLIqCdCWuKovaR7Vq5fY8",
+	"qllo2PVvC5IZC4v2smNBW3otv3Mrxbgn1MI18raVU+7lSu94IgmFA/cFWhfd0LY4s+tPokj7l6cIuY7K",
+	"ouGkC5LFjsinC59EmYaeSPNOrO/EBfXZHFHIRJEZl7lKLFfl6Q/B0oPwz7HBta9R/8XXdBxG9SG9zJUq",
+	"OsDiQnU0V+CJOh1o8TijE2eL9zHuu43aDCoD0ZvR6aRLCLhOJ42NgWmJKWGJxfWse+F1BSMTPHtDQZBx",
+	"3tcRA1Nv831Eg7GcU/6xj5EzNvyx2FBrf+qWGGFwjg38K5r4zSP/bbycKavq/gcJGOvXpBWasHOlbpfi",
+	"tVckNVGvXhNGg7jpT9Z+qHYkGJZYOI6XlEAwG0rDE3eUybSd1oZWmhpT95/0tcZ0ZOPuj21PFFfD/Shm",
+	"a/JWulMuMXxtsxWCCmHT7JnR+nNRij8HW/pPEWefkC1dSt7wnN/ZE3cQ9kNte4xLHbB+0kTGkvL8dsmc",
+	"GojMpdTG6R5G0/fqXVBb9WZ/1Fa9/Bu9dYz8p5HuzZmmeqfMt9HE0imEpxNKfhBePKuPMX4LOTmM0w1F",
+	"2WFy8ag2W1U6PVKTyxZh9ho5F96CeIzqQGxEdLoAKV/KMME25v6G8tY31UrDPfahF+kQ03ldUpNX39Zm",
+	"RKBRZ2vxLU0Pgqqx5P/hMfzd1VZ2JuIATzO412myReo0lmikzYxTN6hPScVsTNr5kToYzpTLuxWigAJN",
+	"vJUgitbBUoLtkct7KQEXkPrUWws5Gq9sxktxSmuz8L82/f8BAFVJYlPQewAA",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,14 +20,29 @@ import (
 )
 
 type Controller struct {
-	authService *service.AuthService
-	log         *zap.SugaredLogger
+	authService    *service.AuthService
+	tokenService   *service.TokenService
+	webhookService *service.WebhookService
+	auditService   *service.AuditService
+	apiKeyService  *service.APIKeyService
+	log            *zap.SugaredLogger
 }
 
-func NewController(as *service.AuthService, l *zap.SugaredLogger) *Controller {
+func NewController(
+	as *service.AuthService,
+	ts *service.TokenService,
+	ws *service.WebhookService,
+	aus *service.AuditService,
+	aks *service.APIKeyService,
+	l *zap.SugaredLogger,
+) *Controller {
 	return &Controller{
-		authService: as,
-		log:         l,
+		authService:    as,
+		tokenService:   ts,
+		webhookService: ws,
+		auditService:   aus,
+		apiKeyService:  aks,
+		log:            l,
 	}
 }
 
@@ -116,7 +132,7 @@ func (c *Controller) Logout(ctx echo.Context) error {
 	if !ok || token == "" {
 		return echo.NewHTTPError(http.StatusUnauthorized, "access token not found in context")
 	}
-	if err := c.authService.Logout(ctx.Request().Context(), token); err != nil {
+	if err := c.authService.Logout(ctx.Request().Context(), token, userMetadata(ctx)); err != nil {
 		return fmt.Errorf("logout: %w", err)
 	}
 
@@ -128,6 +144,35 @@ func (c *Controller) Logout(ctx echo.Context) error {
 	return nil
 }
 
+func (c *Controller) LogoutAll(ctx echo.Context) error {
+	token, ok := ctx.Get(models.MwTokenKey).(string)
+	if !ok || token == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "access token not found in context")
+	}
+	if err := c.authService.LogoutAll(ctx.Request().Context(), token, userMetadata(ctx)); err != nil {
+		return fmt.Errorf("logout all: %w", err)
+	}
+
+	clearRefreshCookie(ctx)
+
+	if err := ctx.NoContent(http.StatusNoContent); err != nil {
+		return fmt.Errorf("no content: %w", err)
+	}
+	return nil
+}
+
+// RevokeToken (POST /api/v1/auth/revoke)
+func (c *Controller) RevokeToken(ctx echo.Context) error {
+	if err := c.authService.RevokeToken(ctx.Request().Context(), ctx.FormValue("token"), userMetadata(ctx)); err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+
+	if err := ctx.NoContent(http.StatusOK); err != nil {
+		return fmt.Errorf("no content: %w", err)
+	}
+	return nil
+}
+
 // GetUserGUID возвращает публичный GUID пользователя.
 // Внутренний userID (int64) извлекается из контекста, куда он был добавлен
 // middleware аутентификации после проверки access-токена.
@@ -148,6 +193,143 @@ func (c *Controller) GetUserGUID(ctx echo.Context) error {
 	return nil
 }
 
+// ListSessions (GET /api/v1/auth/sessions)
+func (c *Controller) ListSessions(ctx echo.Context) error {
+	userID, ok := ctx.Get(models.MwUserIDKey).(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "user ID not found in context")
+	}
+	token, _ := ctx.Get(models.MwTokenKey).(string)
+
+	sessions, err := c.authService.ListSessions(ctx.Request().Context(), userID, token)
+	if err != nil {
+		return fmt.Errorf("list sessions: %w", err)
+	}
+
+	resp := SessionsResponse{Sessions: make([]Session, 0, len(sessions))}
+	for _, s := range sessions {
+		resp.Sessions = append(resp.Sessions, Session{
+			Id:        s.ID,
+			IpAddress: s.IPAddress,
+			UserAgent: s.UserAgent,
+			Device: Device{
+				Browser: s.Browser,
+				Os:      s.OS,
+				Type:    DeviceType(s.DeviceType),
+			},
+			CreatedAt:       s.CreatedAt,
+			ExpiresAt:       s.ExpiresAt,
+			LastRefreshedAt: s.RefreshedAt,
+			Current:         s.Current,
+		})
+	}
+
+	if err := ctx.JSON(http.StatusOK, resp); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// RevokeSession (DELETE /api/v1/auth/sessions/{session_id})
+func (c *Controller) RevokeSession(ctx echo.Context, sessionID int64) error {
+	userID, ok := ctx.Get(models.MwUserIDKey).(int64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "user ID not found in context")
+	}
+
+	if err := c.authService.RevokeSession(ctx.Request().Context(), userID, sessionID, userMetadata(ctx)); err != nil {
+		// чужая сессия неотличима от несуществующей
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "session not found")
+		}
+		return fmt.Errorf("revoke session: %w", err)
+	}
+
+	if err := ctx.NoContent(http.StatusNoContent); err != nil {
+		return fmt.Errorf("no content: %w", err)
+	}
+	return nil
+}
+
+// IntrospectToken (POST /api/v1/auth/introspect)
+func (c *Controller) IntrospectToken(ctx echo.Context) error {
+	// Тело уже проверено OpenAPI-валидатором (token обязателен)
+	token := ctx.FormValue("token")
+
+	result, err := c.authService.IntrospectToken(ctx.Request().Context(), token)
+	if err != nil {
+		return fmt.Errorf("introspect token: %w", err)
+	}
+
+	resp := IntrospectionResponse{Active: result.Active}
+	if result.Active {
+		tokenType := IntrospectionResponseTokenType(result.TokenType)
+		sub := strconv.FormatInt(result.UserID, 10)
+		exp := result.ExpiresAt.Unix()
+		iat := result.IssuedAt.Unix()
+		guid, err := uuid.Parse(result.GUID)
+		if err != nil {
+			return fmt.Errorf("parse guid: %w", err)
+		}
+
+		resp.TokenType = &tokenType
+		resp.Sub = &sub
+		resp.Guid = &guid
+		resp.Exp = &exp
+		resp.Iat = &iat
+		resp.Jti = &result.JTI
+	}
+
+	// RFC 7662: ответ не должен кэшироваться
+	ctx.Response().Header().Set("Cache-Control", "no-store")
+	if err := ctx.JSON(http.StatusOK, resp); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// GetJWKS (GET /api/v1/.well-known/jwks.json)
+func (c *Controller) GetJWKS(ctx echo.Context) error {
+	jwks := c.tokenService.JWKS()
+
+	keys := make([]JWK, 0, len(jwks))
+	for _, k := range jwks {
+		keys = append(keys, JWK{
+			Kty: JWKKty(k.Kty),
+			Kid: k.Kid,
+			Use: k.Use,
+			Alg: k.Alg,
+			N:   optionalString(k.N),
+			E:   optionalString(k.E),
+			Crv: optionalString(k.Crv),
+			X:   optionalString(k.X),
+			Y:   optionalString(k.Y),
+		})
+	}
+
+	// Resource servers кэшируют ключи, при ротации kid меняется
+	ctx.Response().Header().Set("Cache-Control", "public, max-age=300")
+	if err := ctx.JSON(http.StatusOK, JWKSet{Keys: keys}); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// userMetadata IP и User-Agent клиента для привязки сессии и журнала аудита
+func userMetadata(ctx echo.Context) models.UserMetadata {
+	return models.UserMetadata{
+		UserAgent: ctx.Request().UserAgent(),
+		IPAddress: ctx.RealIP(),
+	}
+}
+
 func setRefreshCookie(ctx echo.Context, token string) {
 	cookie := new(http.Cookie)
 	cookie.Name = "refresh_token"
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/service"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+const defaultWebhookDeliveriesLimit = 50
+
+// ListWebhookEndpoints (GET /api/v1/webhooks/endpoints)
+func (c *Controller) ListWebhookEndpoints(ctx echo.Context) error {
+	endpoints, err := c.webhookService.ListEndpoints(ctx.Request().Context())
+	if err != nil {
+		return fmt.Errorf("list webhook endpoints: %w", err)
+	}
+
+	resp := WebhookEndpointsResponse{Endpoints: make([]WebhookEndpoint, 0, len(endpoints))}
+	for _, e := range endpoints {
+		resp.Endpoints = append(resp.Endpoints, toWebhookEndpoint(e, false))
+	}
+
+	if err := ctx.JSON(http.StatusOK, resp); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// CreateWebhookEndpoint (POST /api/v1/webhooks/endpoints)
+func (c *Controller) CreateWebhookEndpoint(ctx echo.Context) error {
+	var req WebhookEndpointCreateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	endpoint := models.WebhookEndpoint{
+		URL:     req.Url,
+		Events:  fromWebhookEventTypes(req.Events),
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.Secret != nil {
+		endpoint.Secret = *req.Secret
+	}
+	if req.Format != nil {
+		endpoint.Format = string(*req.Format)
+	}
+
+	created, err := c.webhookService.CreateEndpoint(ctx.Request().Context(), endpoint)
+	if err != nil {
+		return webhookEndpointError(err)
+	}
+
+	// Секрет показывается только при создании
+	if err := ctx.JSON(http.StatusCreated, toWebhookEndpoint(*created, true)); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookEndpoint (GET /api/v1/webhooks/endpoints/{endpoint_id})
+func (c *Controller) GetWebhookEndpoint(ctx echo.Context, endpointID int64) error {
+	endpoint, err := c.webhookService.GetEndpoint(ctx.Request().Context(), endpointID)
+	if err != nil {
+		return webhookEndpointError(err)
+	}
+
+	if err := ctx.JSON(http.StatusOK, toWebhookEndpoint(*endpoint, false)); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// UpdateWebhookEndpoint (PATCH /api/v1/webhooks/endpoints/{endpoint_id})
+func (c *Controller) UpdateWebhookEndpoint(ctx echo.Context, endpointID int64) error {
+	var req WebhookEndpointUpdateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	update := models.WebhookEndpointUpdate{
+		URL:         req.Url,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+	}
+	if req.Events != nil {
+		update.Events = fromWebhookEventTypes(*req.Events)
+	}
+	if req.Format != nil {
+		format := string(*req.Format)
+		update.Format = &format
+	}
+	rotateSecret := req.RotateSecret != nil && *req.RotateSecret
+
+	updated, err := c.webhookService.UpdateEndpoint(ctx.Request().Context(), endpointID, update, rotateSecret)
+	if err != nil {
+		return webhookEndpointError(err)
+	}
+
+	if err := ctx.JSON(http.StatusOK, toWebhookEndpoint(*updated, rotateSecret)); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhookEndpoint (DELETE /api/v1/webhooks/endpoints/{endpoint_id})
+func (c *Controller) DeleteWebhookEndpoint(ctx echo.Context, endpointID int64) error {
+	if err := c.webhookService.DeleteEndpoint(ctx.Request().Context(), endpointID); err != nil {
+		return webhookEndpointError(err)
+	}
+
+	if err := ctx.NoContent(http.StatusNoContent); err != nil {
+		return fmt.Errorf("no content: %w", err)
+	}
+	return nil
+}
+
+// SendTestWebhook (POST /api/v1/webhooks/endpoints/{endpoint_id}/test)
+func (c *Controller) SendTestWebhook(ctx echo.Context, endpointID int64) error {
+	delivery, err := c.webhookService.SendTestEvent(ctx.Request().Context(), endpointID)
+	if err != nil {
+		return webhookEndpointError(err)
+	}
+
+	resp, err := toWebhookDelivery(delivery)
+	if err != nil {
+		return err
+	}
+	if err := ctx.JSON(http.StatusOK, resp); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries (GET /api/v1/webhooks/endpoints/{endpoint_id}/deliveries)
+func (c *Controller) ListWebhookDeliveries(
+	ctx echo.Context,
+	endpointID int64,
+	params ListWebhookDeliveriesParams,
+) error {
+	limit := defaultWebhookDeliveriesLimit
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	deliveries, err := c.webhookService.ListDeliveries(ctx.Request().Context(), endpointID, limit)
+	if err != nil {
+		return webhookEndpointError(err)
+	}
+
+	resp := WebhookDeliveriesResponse{Deliveries: make([]WebhookDelivery, 0, len(deliveries))}
+	for _, d := range deliveries {
+		delivery, err := toWebhookDelivery(d)
+		if err != nil {
+			return err
+		}
+		resp.Deliveries = append(resp.Deliveries, delivery)
+	}
+
+	if err := ctx.JSON(http.StatusOK, resp); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+func webhookEndpointError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrWebhookEndpointNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "webhook endpoint not found")
+	case errors.Is(err, storage.ErrWebhookEndpointExists):
+		return echo.NewHTTPError(http.StatusConflict, "webhook endpoint with this url already exists")
+	case errors.Is(err, service.ErrInvalidWebhookURL),
+		errors.Is(err, service.ErrUnknownEventType),
+		errors.Is(err, service.ErrUnknownWebhookFormat):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return err
+	}
+}
+
+func toWebhookEndpoint(e models.WebhookEndpoint, withSecret bool) WebhookEndpoint {
+	events := make([]WebhookEventType, 0, len(e.Events))
+	for _, eventType := range e.Events {
+		events = append(events, WebhookEventType(eventType))
+	}
+
+	resp := WebhookEndpoint{
+		Id:          e.ID,
+		Url:         e.URL,
+		Description: e.Description,
+		Enabled:     e.Enabled,
+		Format:      WebhookFormat(e.Format),
+		Events:      events,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+	if withSecret {
+		resp.Secret = &e.Secret
+	}
+	return resp
+}
+
+func fromWebhookEventTypes(events []WebhookEventType) []string {
+	eventTypes := make([]string, 0, len(events))
+	for _, eventType := range events {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+	return eventTypes
+}
+
+func toWebhookDelivery(d models.WebhookDelivery) (WebhookDelivery, error) {
+	eventID, err := uuid.Parse(d.EventID)
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("parse event id: %w", err)
+	}
+	return WebhookDelivery{
+		Id:              d.ID,
+		EventId:         eventID,
+		EventType:       d.EventType,
+		Attempt:         d.Attempt,
+		Success:         d.Success,
+		StatusCode:      d.StatusCode,
+		LatencyMs:       d.Latency.Milliseconds(),
+		ResponseSnippet: optionalString(d.ResponseSnippet),
+		Error:           optionalString(d.Error),
+		CreatedAt:       d.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "auth"
+
+// Операции с токенами (label operation у auth_token_operations_total)
+const (
+	OperationIssue         = "issue"
+	OperationRefresh       = "refresh"
+	OperationLogout        = "logout"
+	OperationLogoutAll     = "logout_all"
+	OperationRevoke        = "revoke"
+	OperationSessionRevoke = "session_revoke"
+
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+
+	// Причины срабатывания защиты от кражи refresh-токена
+	TheftTokenReuse        = "token_reuse"
+	TheftUserAgentMismatch = "user_agent_mismatch"
+
+	// Исходы попытки доставки webhook'а
+	WebhookDelivered = "delivered"
+	WebhookRetry     = "retry"
+	WebhookDead      = "dead"
+	WebhookReleased  = "released"
+
+	// UnknownOperation запрос не попал ни в одну операцию OpenAPI (404, 405)
+	UnknownOperation = "unknown"
+)
+
+// Metrics метрики сервиса в собственном реестре: /metrics на admin-порту отдает только их
+// и стандартные метрики Go-рантайма и процесса
+type Metrics struct {
+	registry *prometheus.Registry
+
+	TokenOperations     *prometheus.CounterVec
+	TheftDetections     *prometheus.CounterVec
+	RateLimitRejections *prometheus.CounterVec
+	WebhookDeliveries   *prometheus.CounterVec
+	WebhookLatency      *prometheus.HistogramVec
+	HTTPRequestDuration *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		TokenOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "token_operations_total",
+			Help:      "Token operations by operation and outcome.",
+		}, []string{"operation", "outcome"}),
+		TheftDetections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "theft_detections_total",
+			Help:      "Refresh token theft detections that revoked sessions, by reason.",
+		}, []string{"reason"}),
+		RateLimitRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_rejections_total",
+			Help:      "Requests rejected by the rate limiter, by OpenAPI operation.",
+		}, []string{"operation"}),
+		WebhookDeliveries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_deliveries_total",
+			Help:      "Webhook delivery attempts by resulting outbox state.",
+		}, []string{"outcome"}),
+		WebhookLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "webhook_delivery_duration_seconds",
+			Help:      "Webhook delivery attempt latency.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by OpenAPI operation and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.TokenOperations,
+		m.TheftDetections,
+		m.RateLimitRejections,
+		m.WebhookDeliveries,
+		m.WebhookLatency,
+		m.HTTPRequestDuration,
+	)
+	return m
+}
+
+// TokenOperation считает операцию: err == nil - success, иначе failure
+func (m *Metrics) TokenOperation(operation string, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeFailure
+	}
+	m.TokenOperations.WithLabelValues(operation, outcome).Inc()
+}
+
+// RegisterDB добавляет статистику пула соединений Postgres
+func (m *Metrics) RegisterDB(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// RegisterRedis добавляет статистику пула соединений Redis
+func (m *Metrics) RegisterRedis(client *redis.Client) {
+	m.registry.MustRegister(newRedisPoolCollector(client))
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE signing_keys (
+    kid TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL,
+    key_material BYTEA NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    activates_at TIMESTAMPTZ NOT NULL,
+    retires_at TIMESTAMPTZ
+);
+
+-- Подписывать может только один ключ
+CREATE UNIQUE INDEX signing_keys_single_active ON signing_keys (status) WHERE status = 'active';
+
+-- +goose Down
+DROP TABLE IF EXISTS signing_keys;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- created_at - начало сессии (логин), refreshed_at - последняя ротация refresh-токена
+ALTER TABLE sessions ADD COLUMN refreshed_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN IF EXISTS refreshed_at;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Семейство - цепочка refresh-токенов, полученных ротацией из одного логина
+ALTER TABLE sessions ADD COLUMN family_id UUID;
+UPDATE sessions SET family_id = gen_random_uuid() WHERE family_id IS NULL;
+ALTER TABLE sessions ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX ON sessions (family_id);
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN IF EXISTS family_id;
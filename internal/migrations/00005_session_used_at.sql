@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN used_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN IF EXISTS used_at;
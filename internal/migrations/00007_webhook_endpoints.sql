@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE webhook_endpoints (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL UNIQUE,
+    secret TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- event_type = '*' - подписка на все типы событий
+CREATE TABLE webhook_subscriptions (
+    endpoint_id BIGINT NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    PRIMARY KEY (endpoint_id, event_type)
+);
+
+-- Недоставленные события без получателя доставить уже некуда
+UPDATE webhook_outbox SET status = 'dead', locked_until = NULL, last_error = 'event predates webhook endpoints'
+    WHERE status IN ('pending', 'delivering');
+
+-- Событие размножается по подписанным endpoint'ам: одна строка outbox на пару (событие, получатель)
+ALTER TABLE webhook_outbox ADD COLUMN endpoint_id BIGINT REFERENCES webhook_endpoints(id) ON DELETE CASCADE;
+ALTER TABLE webhook_outbox DROP CONSTRAINT webhook_outbox_event_id_key;
+ALTER TABLE webhook_outbox ADD CONSTRAINT webhook_outbox_event_endpoint_key UNIQUE (event_id, endpoint_id);
+
+-- +goose Down
+ALTER TABLE webhook_outbox DROP CONSTRAINT IF EXISTS webhook_outbox_event_endpoint_key;
+DELETE FROM webhook_outbox a USING webhook_outbox b WHERE a.event_id = b.event_id AND a.id > b.id;
+ALTER TABLE webhook_outbox ADD CONSTRAINT webhook_outbox_event_id_key UNIQUE (event_id);
+ALTER TABLE webhook_outbox DROP COLUMN IF EXISTS endpoint_id;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS webhook_endpoints;
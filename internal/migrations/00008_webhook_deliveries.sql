@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE webhook_endpoints ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+-- История попыток доставки: одна строка на попытку, включая тестовые события
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    endpoint_id BIGINT NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    outbox_id BIGINT REFERENCES webhook_outbox(id) ON DELETE SET NULL,
+    event_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    attempt INT NOT NULL,
+    success BOOLEAN NOT NULL,
+    status_code INT,
+    latency_ms BIGINT NOT NULL,
+    response_snippet TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries (endpoint_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_deliveries;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS description;
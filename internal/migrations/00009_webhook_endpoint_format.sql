@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE webhook_endpoints ADD COLUMN format TEXT NOT NULL DEFAULT 'json'
+    CHECK (format IN ('json', 'cloudevents-structured', 'cloudevents-binary'));
+
+-- +goose Down
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS format;
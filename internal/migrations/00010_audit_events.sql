@@ -0,0 +1,41 @@
+-- +goose Up
+-- Журнал аутентификации. Строки связаны цепочкой хэшей: hash = sha256(prev_hash || строка),
+-- поэтому изменение или удаление строки в обход триггера обнаруживается проверкой цепочки.
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    event_type TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    user_id BIGINT,
+    session_id BIGINT,
+    client_ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL CHECK (outcome IN ('success', 'failure')),
+    reason TEXT NOT NULL DEFAULT '',
+    prev_hash BYTEA NOT NULL,
+    hash BYTEA NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_audit_events_occurred_at ON audit_events (occurred_at);
+CREATE INDEX idx_audit_events_user_id ON audit_events (user_id, occurred_at);
+CREATE INDEX idx_audit_events_event_type ON audit_events (event_type, occurred_at);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_events_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
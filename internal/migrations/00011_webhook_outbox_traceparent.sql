@@ -0,0 +1,6 @@
+-- +goose Up
+-- W3C traceparent запроса, породившего событие: доставка продолжает его трейс
+ALTER TABLE webhook_outbox ADD COLUMN traceparent TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE webhook_outbox DROP COLUMN IF EXISTS traceparent;
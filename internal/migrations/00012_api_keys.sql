@@ -0,0 +1,19 @@
+-- +goose Up
+-- Именованные API-ключи сервисов-клиентов. Ключ "ak_<prefix>_<secret>": по prefix строка
+-- находится без перебора, сам ключ хранится только в виде SHA-256
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_keys_name ON api_keys (name);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- key_material шифруется ключом JWT_KEY_ENCRYPTION_KEY (AES-256-GCM, kid - associated data).
+-- Строки, записанные до миграции, шифруются при старте сервиса
+ALTER TABLE signing_keys ADD COLUMN encrypted BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- kid удаленных выведенных ключей: ключ из конфигурации с таким kid не возвращается в связку
+-- и не подменяет текущий ключ после рестарта
+CREATE TABLE signing_key_tombstones (
+    kid TEXT PRIMARY KEY,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS signing_key_tombstones;
+ALTER TABLE signing_keys DROP COLUMN IF EXISTS encrypted;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- 00007 переводил в dead недоставленные события, записанные до появления endpoint'ов.
+-- Возвращаем их в очередь без получателя: при старте сервис привязывает их к endpoint'у
+-- из WEBHOOK_URL (SyncWebhookEndpointTx), до этого воркеры их не забирают
+UPDATE webhook_outbox SET status = 'pending', attempts = 0, next_attempt_at = NOW(), last_error = NULL
+    WHERE status = 'dead' AND endpoint_id IS NULL AND last_error = 'event predates webhook endpoints';
+
+-- +goose Down
+UPDATE webhook_outbox SET status = 'dead', locked_until = NULL, last_error = 'event predates webhook endpoints'
+    WHERE status IN ('pending', 'delivering') AND endpoint_id IS NULL;
@@ -0,0 +1,93 @@
+-- +goose Up
+-- Записи вставляются в транзакциях выдачи, ротации и отзыва без хэша и без общей блокировки,
+-- в цепочку их связывает фоновый процесс сервиса, один на все реплики (AuditService.Run).
+-- Транзакции коммитятся не в порядке id, поэтому порядок цепочки задает связывание: chain_seq.
+-- hash = sha256(prev_hash || канонический JSON записи, включая id)
+ALTER TABLE audit_events
+    ALTER COLUMN prev_hash DROP NOT NULL,
+    ALTER COLUMN hash DROP NOT NULL,
+    ADD COLUMN chain_seq BIGINT UNIQUE;
+
+CREATE INDEX idx_audit_events_unlinked ON audit_events (id) WHERE hash IS NULL;
+
+-- Хэш записей до этой миграции не включал id: цепочка строится заново в порядке id
+DROP TRIGGER audit_events_no_update_delete ON audit_events;
+UPDATE audit_events SET prev_hash = NULL, hash = NULL;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Единственное разрешенное изменение записи - связывание: заполнение prev_hash, hash и chain_seq
+-- +goose StatementBegin
+CREATE FUNCTION audit_events_link_only() RETURNS trigger AS $$
+BEGIN
+    IF OLD.hash IS NULL AND NEW.hash IS NOT NULL AND NEW.prev_hash IS NOT NULL AND NEW.chain_seq IS NOT NULL
+        AND (NEW.id, NEW.occurred_at, NEW.event_type, NEW.actor, NEW.user_id, NEW.session_id, NEW.client_ip,
+             NEW.user_agent, NEW.outcome, NEW.reason)
+            IS NOT DISTINCT FROM
+            (OLD.id, OLD.occurred_at, OLD.event_type, OLD.actor, OLD.user_id, OLD.session_id, OLD.client_ip,
+             OLD.user_agent, OLD.outcome, OLD.reason) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_events_link_only
+    BEFORE UPDATE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_link_only();
+
+CREATE TRIGGER audit_events_no_delete
+    BEFORE DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+-- Одна строка: последняя связанная запись. Обновляется в транзакции связывания и только вперед.
+-- Удаление записей с конца журнала не разрывает ссылки, но расходится с ней
+CREATE TABLE audit_chain_head (
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+    chain_seq BIGINT NOT NULL,
+    event_id BIGINT NOT NULL,
+    hash BYTEA NOT NULL
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_chain_head_forward_only() RETURNS trigger AS $$
+BEGIN
+    IF NEW.chain_seq <= OLD.chain_seq THEN
+        RAISE EXCEPTION 'audit_chain_head can only move forward';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_chain_head_forward_only
+    BEFORE UPDATE ON audit_chain_head
+    FOR EACH ROW EXECUTE FUNCTION audit_chain_head_forward_only();
+
+CREATE TRIGGER audit_chain_head_no_delete
+    BEFORE DELETE ON audit_chain_head
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_chain_head_no_truncate
+    BEFORE TRUNCATE ON audit_chain_head
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+-- +goose Down
+-- Связанные записи сохраняют хэш нового формата, несвязанные остаются без хэша
+DROP TABLE IF EXISTS audit_chain_head;
+DROP FUNCTION IF EXISTS audit_chain_head_forward_only();
+DROP TRIGGER IF EXISTS audit_events_link_only ON audit_events;
+DROP TRIGGER IF EXISTS audit_events_no_delete ON audit_events;
+DROP FUNCTION IF EXISTS audit_events_link_only();
+CREATE TRIGGER audit_events_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+DROP INDEX IF EXISTS idx_audit_events_unlinked;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS chain_seq;
@@ -1,17 +1,68 @@
 package migrations
 
 import (
+	"context"
 	"database/sql"
+	"embed"
+	"errors"
 	"fmt"
 
 	"github.com/pressly/goose/v3"
 	"go.uber.org/zap"
 )
 
-func RunMigrations(db *sql.DB, logger *zap.SugaredLogger, migrationsDir string) error {
+// migrationsDir корень миграций внутри встроенной FS
+const migrationsDir = "."
+
+// Миграции встроены в бинарник: сервису и CLI не нужен каталог с исходниками рядом
+//
+//go:embed *.sql
+var embedded embed.FS
+
+func init() {
+	goose.SetBaseFS(embedded)
+}
+
+var ErrSchemaOutdated = errors.New("database schema is behind the expected version")
+
+func RunMigrations(db *sql.DB, logger *zap.SugaredLogger) error {
 	if err := goose.Up(db, migrationsDir); err != nil {
 		return fmt.Errorf("migrations: %w", err)
 	}
 	logger.Info("Database migrations applied successfully!")
 	return nil
 }
+
+// Command команда goose (up, down, status, redo) для CLI
+func Command(ctx context.Context, db *sql.DB, command string) error {
+	if err := goose.RunContext(ctx, command, db, migrationsDir); err != nil {
+		return fmt.Errorf("migrate %s: %w", command, err)
+	}
+	return nil
+}
+
+// NewVersionCheck возвращает проверку, что схема в БД не отстает от последней встроенной миграции.
+// Схема новее бинарника допустима: во время rolling deploy новая реплика уже мигрировала БД,
+// а старые еще обслуживают запросы. Последняя версия определяется один раз, при создании проверки.
+func NewVersionCheck(db *sql.DB) (func(ctx context.Context) error, error) {
+	migrations, err := goose.CollectMigrations(migrationsDir, 0, goose.MaxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("collect migrations: %w", err)
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return nil, fmt.Errorf("last migration: %w", err)
+	}
+	expected := last.Version
+
+	return func(ctx context.Context) error {
+		current, err := goose.GetDBVersionContext(ctx, db)
+		if err != nil {
+			return fmt.Errorf("get schema version: %w", err)
+		}
+		if current < expected {
+			return fmt.Errorf("%w: %d, expected %d", ErrSchemaOutdated, current, expected)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+const (
+	// APIKeyPrefix начало ключей, хранящихся в БД: "ak_<prefix>_<secret>".
+	// Ключ без него - общий ключ из AUTH_SERVICE_API_KEY
+	APIKeyPrefix = "ak"
+
+	// APIKeyScopeAll доступ ко всем операциям, в том числе добавленным позже
+	APIKeyScopeAll = "*"
+
+	APIKeyScopeTokensIssue      = "tokens:issue"
+	APIKeyScopeTokensRevoke     = "tokens:revoke"
+	APIKeyScopeTokensIntrospect = "tokens:introspect"
+	APIKeyScopeWebhooksRead     = "webhooks:read"
+	APIKeyScopeWebhooksWrite    = "webhooks:write"
+	APIKeyScopeAuditRead        = "audit:read"
+	// APIKeyScopeAdmin управление API-ключами: ключ с ним может выпустить ключ с любыми scope'ами
+	APIKeyScopeAdmin = "api_keys:admin"
+
+	// LegacyAPIKeyName имя, под которым в логах и аудите виден общий ключ из AUTH_SERVICE_API_KEY
+	LegacyAPIKeyName = "default"
+
+	// APIKeyTouchInterval last_used_at обновляется не чаще: иначе каждый запрос по ключу - запись в БД
+	APIKeyTouchInterval = time.Minute
+)
+
+// APIKeyScopes каталог scope'ов, которые можно выдать ключу
+func APIKeyScopes() []string {
+	return []string{
+		APIKeyScopeAll,
+		APIKeyScopeTokensIssue,
+		APIKeyScopeTokensRevoke,
+		APIKeyScopeTokensIntrospect,
+		APIKeyScopeWebhooksRead,
+		APIKeyScopeWebhooksWrite,
+		APIKeyScopeAuditRead,
+		APIKeyScopeAdmin,
+	}
+}
+
+// APIKey ключ сервиса-клиента. Сам ключ не хранится, только KeyHash
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Usable ключ не отозван и не истек
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// TouchDue пора ли обновить LastUsedAt
+func (k *APIKey) TouchDue(now time.Time) bool {
+	return k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= APIKeyTouchInterval
+}
+
+// APIKeyIdentity кто вызвал API. Прикрепляется к запросу после проверки ключа
+type APIKeyIdentity struct {
+	// ID 0 у общего ключа из AUTH_SERVICE_API_KEY
+	ID     int64
+	Name   string
+	Prefix string
+	Scopes []string
+}
+
+// HasScopes есть ли у ключа все scope'ы required
+func (k *APIKeyIdentity) HasScopes(required []string) bool {
+	if slices.Contains(k.Scopes, APIKeyScopeAll) {
+		return true
+	}
+	for _, scope := range required {
+		if !slices.Contains(k.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+type apiKeyContextKey struct{}
+
+// ContextWithAPIKey прикрепляет ключ вызывающего к контексту запроса: по нему сервисы пишут actor аудита
+func ContextWithAPIKey(ctx context.Context, identity *APIKeyIdentity) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, identity)
+}
+
+func APIKeyFromContext(ctx context.Context) (*APIKeyIdentity, bool) {
+	identity, ok := ctx.Value(apiKeyContextKey{}).(*APIKeyIdentity)
+	return identity, ok
+}
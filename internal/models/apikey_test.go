@@ -0,0 +1,35 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+)
+
+func TestAPIKeyTouchDue(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		ts := now.Add(-d)
+		return &ts
+	}
+
+	tests := []struct {
+		name       string
+		lastUsedAt *time.Time
+		want       bool
+	}{
+		{name: "never used", want: true},
+		{name: "just used", lastUsedAt: at(0)},
+		{name: "used within the interval", lastUsedAt: at(models.APIKeyTouchInterval - time.Second)},
+		{name: "interval passed", lastUsedAt: at(models.APIKeyTouchInterval), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := models.APIKey{LastUsedAt: tt.lastUsedAt}
+			if got := key.TouchDue(now); got != tt.want {
+				t.Errorf("TouchDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
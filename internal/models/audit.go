@@ -0,0 +1,128 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Типы записей журнала аудита
+const (
+	AuditTokenIssued        = "token.issued"
+	AuditTokenRefreshed     = "token.refreshed"
+	AuditTokenRevoked       = "token.revoked"
+	AuditSessionRevoked     = "session.revoked"
+	AuditLogout             = "logout"
+	AuditLogoutAll          = "logout.all"
+	AuditTokenReuseDetected = "token.reuse_detected"
+	AuditUserAgentMismatch  = "user_agent.mismatch"
+
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+
+	// AuditActorAPIKey вызов сервиса по API-ключу (выдача токенов, RFC 7009).
+	// Если ключ известен, к actor добавляется его имя: "api_key:<name>"
+	AuditActorAPIKey = "api_key"
+	// AuditActorSystem действие сервиса без запроса пользователя (отзыв при обнаружении кражи)
+	AuditActorSystem = "system"
+
+	auditHashSize = sha256.Size
+)
+
+// AuditActorUser действие владельца access- или refresh-токена
+func AuditActorUser(userID int64) string {
+	return "user:" + strconv.FormatInt(userID, 10)
+}
+
+// AuditActorAPIKeyNamed вызов по именованному API-ключу
+func AuditActorAPIKeyNamed(name string) string {
+	return AuditActorAPIKey + ":" + name
+}
+
+// AuditEvent запись журнала аудита.
+// Hash = sha256(PrevHash || канонический JSON остальных полей, включая ID), PrevHash - Hash предыдущей записи.
+// Запись вставляется без хэша, PrevHash, Hash и ChainSeq заполняются при связывании в цепочку.
+type AuditEvent struct {
+	ID         int64     `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	EventType  string    `json:"event_type"`
+	Actor      string    `json:"actor"`
+	UserID     *int64    `json:"user_id,omitempty"`
+	// UserGUID заполняется при чтении журнала и в хэш не входит
+	UserGUID  string `json:"user_guid,omitempty"`
+	SessionID *int64 `json:"session_id,omitempty"`
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+	Outcome   string `json:"outcome"`
+	Reason    string `json:"reason"`
+	PrevHash  []byte `json:"prev_hash"`
+	Hash      []byte `json:"hash"`
+	// ChainSeq позиция в цепочке, 0 - запись еще не связана. В хэш не входит
+	ChainSeq int64 `json:"-"`
+}
+
+// AuditFilter условия выборки журнала. Пустые поля не фильтруют.
+// Курсор - id записи: при Ascending выбираются записи после AfterID, иначе до BeforeID.
+type AuditFilter struct {
+	UserGUID  string
+	EventType string
+	IPAddress string
+	// From включительно, To не включительно
+	From      *time.Time
+	To        *time.Time
+	AfterID   int64
+	BeforeID  int64
+	Ascending bool
+}
+
+// AuditChainHead последняя связанная запись журнала, хранится отдельно от audit_events:
+// по ней обнаруживается удаление записей с конца цепочки. ChainSeq 0 - цепочка пуста
+type AuditChainHead struct {
+	ChainSeq int64
+	EventID  int64
+	Hash     []byte
+}
+
+// GenesisAuditHash PrevHash первой записи цепочки
+func GenesisAuditHash() []byte {
+	return make([]byte, auditHashSize)
+}
+
+// ChainHash вычисляет хэш записи поверх prevHash. Считается по записи, прочитанной из БД,
+// поэтому ID уже выдан, а OccurredAt приведен к точности БД.
+func (e *AuditEvent) ChainHash(prevHash []byte) ([]byte, error) {
+	// Порядок полей структуры фиксирован
+	canonical, err := json.Marshal(struct {
+		ID         int64  `json:"id"`
+		OccurredAt string `json:"occurred_at"`
+		EventType  string `json:"event_type"`
+		Actor      string `json:"actor"`
+		UserID     *int64 `json:"user_id"`
+		SessionID  *int64 `json:"session_id"`
+		IPAddress  string `json:"ip_address"`
+		UserAgent  string `json:"user_agent"`
+		Outcome    string `json:"outcome"`
+		Reason     string `json:"reason"`
+	}{
+		ID:         e.ID,
+		OccurredAt: e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		EventType:  e.EventType,
+		Actor:      e.Actor,
+		UserID:     e.UserID,
+		SessionID:  e.SessionID,
+		IPAddress:  e.IPAddress,
+		UserAgent:  e.UserAgent,
+		Outcome:    e.Outcome,
+		Reason:     e.Reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal audit event: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write(prevHash)
+	h.Write(canonical)
+	return h.Sum(nil), nil
+}
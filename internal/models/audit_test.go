@@ -0,0 +1,84 @@
+package models_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+)
+
+func TestAuditEventChainHash(t *testing.T) {
+	userID, sessionID := int64(7), int64(11)
+	base := models.AuditEvent{
+		ID:         1,
+		OccurredAt: time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+		EventType:  models.AuditTokenIssued,
+		Actor:      models.AuditActorAPIKey,
+		UserID:     &userID,
+		SessionID:  &sessionID,
+		IPAddress:  "192.0.2.1",
+		UserAgent:  "curl/8.0",
+		Outcome:    models.AuditOutcomeSuccess,
+	}
+	genesis := models.GenesisAuditHash()
+
+	want, err := base.ChainHash(genesis)
+	if err != nil {
+		t.Fatalf("ChainHash() error = %v", err)
+	}
+	if len(want) != sha256.Size {
+		t.Fatalf("ChainHash() length = %d, want %d", len(want), sha256.Size)
+	}
+
+	otherUserID := int64(8)
+	tests := []struct {
+		name     string
+		modify   func(e *models.AuditEvent)
+		prevHash []byte
+		same     bool
+	}{
+		{name: "same event", modify: func(*models.AuditEvent) {}, same: true},
+		{
+			name:   "same instant in another time zone",
+			modify: func(e *models.AuditEvent) { e.OccurredAt = e.OccurredAt.In(time.FixedZone("UTC+3", 3*60*60)) },
+			same:   true,
+		},
+		{
+			name:   "fields outside the hash",
+			modify: func(e *models.AuditEvent) { e.UserGUID, e.Hash, e.ChainSeq = "guid", []byte{1}, 5 },
+			same:   true,
+		},
+		{name: "id", modify: func(e *models.AuditEvent) { e.ID = 2 }},
+		{name: "occurred at", modify: func(e *models.AuditEvent) { e.OccurredAt = e.OccurredAt.Add(time.Microsecond) }},
+		{name: "event type", modify: func(e *models.AuditEvent) { e.EventType = models.AuditTokenRevoked }},
+		{name: "actor", modify: func(e *models.AuditEvent) { e.Actor = models.AuditActorSystem }},
+		{name: "user id", modify: func(e *models.AuditEvent) { e.UserID = &otherUserID }},
+		{name: "no user id", modify: func(e *models.AuditEvent) { e.UserID = nil }},
+		{name: "session id", modify: func(e *models.AuditEvent) { e.SessionID = nil }},
+		{name: "ip address", modify: func(e *models.AuditEvent) { e.IPAddress = "192.0.2.2" }},
+		{name: "user agent", modify: func(e *models.AuditEvent) { e.UserAgent = "" }},
+		{name: "outcome", modify: func(e *models.AuditEvent) { e.Outcome = models.AuditOutcomeFailure }},
+		{name: "reason", modify: func(e *models.AuditEvent) { e.Reason = "ip_changed" }},
+		{name: "previous hash", modify: func(*models.AuditEvent) {}, prevHash: want},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := base
+			tt.modify(&event)
+			prevHash := genesis
+			if tt.prevHash != nil {
+				prevHash = tt.prevHash
+			}
+
+			got, err := event.ChainHash(prevHash)
+			if err != nil {
+				t.Fatalf("ChainHash() error = %v", err)
+			}
+			if equal := bytes.Equal(got, want); equal != tt.same {
+				t.Errorf("ChainHash() equal to base event hash = %v, want %v", equal, tt.same)
+			}
+		})
+	}
+}
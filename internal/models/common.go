@@ -9,20 +9,59 @@ const (
 
 	MwAPIKeyHeader = "X-API-Key"
 
-	MwUserIDKey = "userID"
-	MwTokenKey  = "token"
+	MwUserIDKey      = "userID"
+	MwAPIKeyKey      = "apiKey"
+	MwTokenKey       = "token"
+	MwOperationIDKey = "operationID"
 )
 
 type RefreshSession struct {
-	ID             int64     `json:"id"`
-	UserID         int64     `json:"user_id"`
-	Selector       string    `json:"selector"`
-	VerifierHash   string    `json:"verifier_hash"`
-	UserAgent      string    `json:"user_agent"`
-	IPAddress      string    `json:"ip_address"`
-	AccessTokenJTI string    `json:"access_token_jti"`
-	CreatedAt      time.Time `json:"created_at"`
-	ExpiresAt      time.Time `json:"expires_at"`
+	ID             int64  `json:"id"`
+	UserID         int64  `json:"user_id"`
+	Selector       string `json:"selector"`
+	VerifierHash   string `json:"verifier_hash"`
+	UserAgent      string `json:"user_agent"`
+	IPAddress      string `json:"ip_address"`
+	AccessTokenJTI string `json:"access_token_jti"`
+	Status         string `json:"status"`
+	// FamilyID общий для всех refresh-токенов, полученных ротацией из одного логина
+	FamilyID string `json:"family_id"`
+	// CreatedAt момент логина, при ротации переносится в новую сессию
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RefreshedAt *time.Time `json:"refreshed_at,omitempty"`
+}
+
+// SessionInfo сессия пользователя для списка "где я залогинен"
+type SessionInfo struct {
+	ID          int64      `json:"id"`
+	IPAddress   string     `json:"ip_address"`
+	UserAgent   string     `json:"user_agent"`
+	Browser     string     `json:"browser"`
+	OS          string     `json:"os"`
+	DeviceType  string     `json:"device_type"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RefreshedAt *time.Time `json:"refreshed_at,omitempty"`
+	Current     bool       `json:"current"`
+}
+
+// RevokeTarget какие сессии удаляет RevokeSessionsTx. Заполняется одно из полей;
+// UserID вместе с SessionID ограничивает сессию пользователем, без него - все сессии пользователя.
+// Кроме FamilyID и UserID без SessionID удаляется только активная сессия: использованные остаются
+// для обнаружения повторного использования.
+type RevokeTarget struct {
+	AccessTokenJTI string
+	Selector       string
+	SessionID      int64
+	FamilyID       string
+	UserID         int64
+}
+
+// RevokeResult результат RevokeSessionsTx. FamilyID заполнен при удалении по AccessTokenJTI
+type RevokeResult struct {
+	Deleted  int64
+	FamilyID string
 }
 
 type RefreshToken struct {
@@ -37,6 +76,45 @@ type UserMetadata struct {
 	IPAddress string `json:"ip_address"`
 }
 
+const (
+	SessionStatusActive = "active"
+	SessionStatusUsed   = "used"
+
+	// Что отзывать при повторном использовании refresh-токена
+	RevokeScopeFamily = "family"
+	RevokeScopeUser   = "user"
+
+	TokenTypeAccess  = "access_token"
+	TokenTypeRefresh = "refresh_token"
+
+	// По чему считается лимит запросов политики rate limit
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyAPIKey = "api_key"
+	RateLimitKeyUser   = "user"
+	RateLimitKeyGUID   = "guid"
+
+	// OperationRefreshTokens операция обновления пары: access-токен может быть просрочен,
+	// поэтому bearer-аутентификация не выполняется и пользователь до обработчика неизвестен
+	OperationRefreshTokens = "RefreshTokens"
+)
+
+// RateLimitKeys ключи лимитов. ip и guid известны до аутентификации, api_key и user - после
+func RateLimitKeys() []string {
+	return []string{RateLimitKeyIP, RateLimitKeyAPIKey, RateLimitKeyUser, RateLimitKeyGUID}
+}
+
+// TokenIntrospection результат проверки токена (RFC 7662).
+// Для неактивного токена заполнено только Active.
+type TokenIntrospection struct {
+	Active    bool      `json:"active"`
+	TokenType string    `json:"token_type"`
+	UserID    int64     `json:"sub"`
+	GUID      string    `json:"guid"`
+	JTI       string    `json:"jti"`
+	IssuedAt  time.Time `json:"iat"`
+	ExpiresAt time.Time `json:"exp"`
+}
+
 type User struct {
 	ID   int64  `json:"id"`
 	GUID string `json:"guid"`
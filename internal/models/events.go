@@ -0,0 +1,138 @@
+package models
+
+import "time"
+
+// Типы событий безопасности, на которые подписываются webhook endpoint'ы
+const (
+	EventSessionCreated     = "session.created"
+	EventSessionRefreshed   = "session.refreshed"
+	EventSessionRevoked     = "session.revoked"
+	EventTokenReuseDetected = "token.reuse_detected"
+	EventUserAgentMismatch  = "user_agent.mismatch"
+	EventIPChanged          = "ip.changed"
+	EventLogout             = "logout"
+
+	// EventWebhookTest отправляется только по запросу, подписаться на него нельзя
+	EventWebhookTest = "webhook.test"
+
+	// Причины session.revoked
+	RevokeReasonUser       = "user"
+	RevokeReasonRevocation = "token_revocation"
+)
+
+// WebhookEventTypes все типы событий каталога
+func WebhookEventTypes() []string {
+	return []string{
+		EventSessionCreated,
+		EventSessionRefreshed,
+		EventSessionRevoked,
+		EventTokenReuseDetected,
+		EventUserAgentMismatch,
+		EventIPChanged,
+		EventLogout,
+	}
+}
+
+// EventData полезная нагрузка события.
+// Схема данных стабильна в пределах версии: поля только добавляются,
+// несовместимое изменение - новая структура с новой версией.
+type EventData interface {
+	EventType() string
+	EventVersion() int
+}
+
+// EventEnvelope тело webhook-доставки
+type EventEnvelope struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       EventData `json:"data"`
+}
+
+// SessionCreatedV1 выдана новая пара токенов (логин)
+type SessionCreatedV1 struct {
+	UserID    int64  `json:"user_id"`
+	GUID      string `json:"guid"`
+	FamilyID  string `json:"family_id"`
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+}
+
+func (SessionCreatedV1) EventType() string { return EventSessionCreated }
+func (SessionCreatedV1) EventVersion() int { return 1 }
+
+// SessionRefreshedV1 refresh-токен ротирован
+type SessionRefreshedV1 struct {
+	UserID    int64  `json:"user_id"`
+	FamilyID  string `json:"family_id"`
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+}
+
+func (SessionRefreshedV1) EventType() string { return EventSessionRefreshed }
+func (SessionRefreshedV1) EventVersion() int { return 1 }
+
+// SessionRevokedV1 сессия (вместе с ее семейством) отозвана явно
+type SessionRevokedV1 struct {
+	UserID   int64  `json:"user_id"`
+	FamilyID string `json:"family_id"`
+	Reason   string `json:"reason"`
+}
+
+func (SessionRevokedV1) EventType() string { return EventSessionRevoked }
+func (SessionRevokedV1) EventVersion() int { return 1 }
+
+// TokenReuseDetectedV1 предъявлен уже использованный refresh-токен, сессии отозваны в радиусе Scope
+type TokenReuseDetectedV1 struct {
+	UserID    int64  `json:"user_id"`
+	FamilyID  string `json:"family_id"`
+	Scope     string `json:"scope"`
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+}
+
+func (TokenReuseDetectedV1) EventType() string { return EventTokenReuseDetected }
+func (TokenReuseDetectedV1) EventVersion() int { return 1 }
+
+// UserAgentMismatchV1 refresh с другим User-Agent, сессии отозваны в радиусе Scope
+type UserAgentMismatchV1 struct {
+	UserID            int64  `json:"user_id"`
+	FamilyID          string `json:"family_id"`
+	Scope             string `json:"scope"`
+	ExpectedUserAgent string `json:"expected_user_agent"`
+	ActualUserAgent   string `json:"actual_user_agent"`
+	IPAddress         string `json:"ip_address"`
+}
+
+func (UserAgentMismatchV1) EventType() string { return EventUserAgentMismatch }
+func (UserAgentMismatchV1) EventVersion() int { return 1 }
+
+// IPChangedV1 refresh с другого IP-адреса
+type IPChangedV1 struct {
+	UserID    int64  `json:"user_id"`
+	FamilyID  string `json:"family_id"`
+	OldIP     string `json:"old_ip"`
+	NewIP     string `json:"new_ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+func (IPChangedV1) EventType() string { return EventIPChanged }
+func (IPChangedV1) EventVersion() int { return 1 }
+
+// LogoutV1 пользователь вышел на текущем устройстве или везде (AllDevices)
+type LogoutV1 struct {
+	UserID     int64 `json:"user_id"`
+	AllDevices bool  `json:"all_devices"`
+}
+
+func (LogoutV1) EventType() string { return EventLogout }
+func (LogoutV1) EventVersion() int { return 1 }
+
+// WebhookTestV1 тестовое событие для проверки получателя
+type WebhookTestV1 struct {
+	EndpointID int64 `json:"endpoint_id"`
+}
+
+func (WebhookTestV1) EventType() string { return EventWebhookTest }
+func (WebhookTestV1) EventVersion() int { return 1 }
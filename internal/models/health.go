@@ -0,0 +1,19 @@
+package models
+
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheckResult результат одной проверки готовности
+type HealthCheckResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReport ответ /readyz: Status ok, только если прошли все проверки
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// JWK публичный ключ в формате RFC 7517.
+// Заполняются только поля, относящиеся к типу ключа (kty).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+const (
+	SigningKeyStatusPending = "pending"
+	SigningKeyStatusActive  = "active"
+	SigningKeyStatusRetired = "retired"
+)
+
+// StoredSigningKey ключ из связки (key ring), общей для всех реплик.
+// KeyMaterial - HMAC-секрет для HS512 или PEM приватного ключа; в БД он зашифрован, если Encrypted.
+type StoredSigningKey struct {
+	KID         string     `json:"kid"`
+	Algorithm   string     `json:"algorithm"`
+	KeyMaterial []byte     `json:"-"`
+	Encrypted   bool       `json:"-"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ActivatesAt time.Time  `json:"activates_at"`
+	RetiresAt   *time.Time `json:"retires_at,omitempty"`
+}
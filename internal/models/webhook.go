@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusDelivering = "delivering"
+	OutboxStatusDelivered  = "delivered"
+	OutboxStatusDead       = "dead"
+
+	// WebhookEventAll подписка на все типы событий, в том числе добавленные позже
+	WebhookEventAll = "*"
+
+	// Формат доставки: собственный конверт или CloudEvents 1.0 в structured/binary HTTP-режиме
+	WebhookFormatJSON                  = "json"
+	WebhookFormatCloudEventsStructured = "cloudevents-structured"
+	WebhookFormatCloudEventsBinary     = "cloudevents-binary"
+)
+
+// OutboxEvent webhook-событие в transactional outbox.
+// Пишется в той же транзакции, что и изменение сессии, отдельной строкой для каждого
+// подписанного endpoint'а, доставляется воркерами WebhookService.
+type OutboxEvent struct {
+	ID            int64           `json:"id"`
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LockedUntil   *time.Time      `json:"locked_until,omitempty"`
+	LastError     *string         `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	// TraceParent W3C traceparent запроса, в котором событие возникло ("" - вне трейса)
+	TraceParent string `json:"-"`
+
+	// Получатель, заполняется при выборке события на доставку
+	EndpointID     int64  `json:"endpoint_id"`
+	EndpointURL    string `json:"-"`
+	EndpointSecret string `json:"-"`
+	EndpointFormat string `json:"-"`
+}
+
+// WebhookEndpoint получатель webhook-событий
+type WebhookEndpoint struct {
+	ID          int64  `json:"id"`
+	URL         string `json:"url"`
+	Secret      string `json:"-"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Format      string `json:"format"`
+	// Events типы событий, на которые подписан endpoint
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookEndpointUpdate изменение endpoint'а: nil - поле не меняется
+type WebhookEndpointUpdate struct {
+	URL         *string
+	Secret      *string
+	Description *string
+	Enabled     *bool
+	Format      *string
+	// Events заменяет подписки целиком
+	Events []string
+}
+
+// WebhookDelivery попытка доставки события получателю
+type WebhookDelivery struct {
+	ID         int64  `json:"id"`
+	EndpointID int64  `json:"endpoint_id"`
+	OutboxID   *int64 `json:"outbox_id,omitempty"`
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	Attempt    int    `json:"attempt"`
+	Success    bool   `json:"success"`
+	// StatusCode nil, если ответ не получен
+	StatusCode      *int          `json:"status_code,omitempty"`
+	Latency         time.Duration `json:"latency"`
+	ResponseSnippet string        `json:"response_snippet"`
+	Error           string        `json:"error"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
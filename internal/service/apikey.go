@@ -2,36 +2,276 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+	"github.com/rryowa/medods_dvortsov/internal/util"
 )
 
 const (
 	CurrentAPIKeyRedisKey      = "apikey:current"
 	OldAPIKeyRedisKey          = "apikey:old"
 	APIKeyRotationTimeRedisKey = "apikey:rotation_time"
-	defaultAPIKeyRedisTTL      = 24 * time.Hour
+
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+	maxAPIKeyNameLen  = 100
+
+	// MaxAPIKeyRotationOverlap наибольший срок, который можно задать при ротации ключа
+	MaxAPIKeyRotationOverlap = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidAPIKey        = errors.New("invalid api key")
+	ErrInvalidAPIKeyRequest = errors.New("invalid api key request")
 )
 
+// APIKeyService проверяет ключи сервисов-клиентов.
+// Именованные ключи со scope'ами хранятся в Postgres (только хеш),
+// общий ключ из AUTH_SERVICE_API_KEY - в Redis и имеет все права.
 type APIKeyService struct {
-	rdb *redis.Client
+	rdb     *redis.Client
+	storage storage.Storage
+	// cfg заменяется при перечитывании конфигурации (SIGHUP)
+	cfg atomic.Pointer[util.APIKeyConfig]
 	log *zap.SugaredLogger
 }
 
-func NewAPIKeyService(rdb *redis.Client, log *zap.SugaredLogger) *APIKeyService {
-	return &APIKeyService{rdb: rdb, log: log}
+func NewAPIKeyService(
+	rdb *redis.Client,
+	s storage.Storage,
+	cfg *util.APIKeyConfig,
+	log *zap.SugaredLogger,
+) *APIKeyService {
+	svc := &APIKeyService{rdb: rdb, storage: s, log: log}
+	svc.cfg.Store(cfg)
+	return svc
+}
+
+// Authenticate проверяет ключ и возвращает, кому он принадлежит.
+// Ключ вида "ak_<prefix>_<secret>" ищется в БД по prefix, любой другой сверяется с общим ключом.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKeyIdentity, error) {
+	prefix, ok := parseAPIKeyPrefix(key)
+	if !ok {
+		valid, err := s.IsValidAPIKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, ErrInvalidAPIKey
+		}
+		return &models.APIKeyIdentity{
+			Name:   models.LegacyAPIKeyName,
+			Scopes: []string{models.APIKeyScopeAll},
+		}, nil
+	}
+
+	stored, err := s.storage.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+
+	hashedKey := s.hashAPIKey(key)
+	if len(hashedKey) != len(stored.KeyHash) ||
+		subtle.ConstantTimeCompare([]byte(hashedKey), []byte(stored.KeyHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now().UTC()
+	if !stored.Usable(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// last_used_at только для информации: пишется не чаще models.APIKeyTouchInterval,
+	// чтобы чтение (introspect) не становилось записью, и ошибка записи не отклоняет запрос
+	if stored.TouchDue(now) {
+		if err := s.storage.TouchAPIKey(ctx, stored.ID, now); err != nil {
+			s.log.Warnw("failed to update api key last use", "apiKeyID", stored.ID, "error", err)
+		}
+	}
+
+	return &models.APIKeyIdentity{
+		ID:     stored.ID,
+		Name:   stored.Name,
+		Prefix: stored.Prefix,
+		Scopes: stored.Scopes,
+	}, nil
+}
+
+// CreateKey выпускает именованный ключ. Сам ключ возвращается один раз, в БД остается только хеш.
+func (s *APIKeyService) CreateKey(
+	ctx context.Context,
+	name string,
+	scopes []string,
+	expiresAt *time.Time,
+) (string, *models.APIKey, error) {
+	now := time.Now().UTC()
+	if err := validateAPIKeyRequest(name, scopes, expiresAt, now); err != nil {
+		return "", nil, err
+	}
+
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	created, err := s.storage.CreateAPIKey(ctx, models.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   s.hashAPIKey(key),
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("create api key: %w", err)
+	}
+	s.log.Infow("api key created", "apiKeyID", created.ID, "name", created.Name, "scopes", created.Scopes)
+	return key, created, nil
+}
+
+// RotateKey выпускает ключ с тем же именем и scope'ами на смену ключа id.
+// Прежний ключ принимается еще overlap (nil - RotationOverlap из конфигурации), затем истекает.
+func (s *APIKeyService) RotateKey(
+	ctx context.Context,
+	id int64,
+	overlap *time.Duration,
+	expiresAt *time.Time,
+) (string, *models.APIKey, error) {
+	gracePeriod := s.cfg.Load().RotationOverlap
+	if overlap != nil {
+		gracePeriod = *overlap
+		if gracePeriod > MaxAPIKeyRotationOverlap {
+			return "", nil, fmt.Errorf("%w: overlap must be at most %s", ErrInvalidAPIKeyRequest, MaxAPIKeyRotationOverlap)
+		}
+	}
+	if gracePeriod < 0 {
+		return "", nil, fmt.Errorf("%w: overlap must not be negative", ErrInvalidAPIKeyRequest)
+	}
+
+	old, err := s.storage.GetAPIKey(ctx, id)
+	if err != nil {
+		return "", nil, fmt.Errorf("get api key: %w", err)
+	}
+	now := time.Now().UTC()
+	if !old.Usable(now) {
+		return "", nil, fmt.Errorf("rotate api key: %w", storage.ErrAPIKeyNotFound)
+	}
+	if err := validateAPIKeyRequest(old.Name, old.Scopes, expiresAt, now); err != nil {
+		return "", nil, err
+	}
+
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	created, err := s.storage.RotateAPIKeyTx(ctx, id, models.APIKey{
+		Name:      old.Name,
+		Prefix:    prefix,
+		KeyHash:   s.hashAPIKey(key),
+		Scopes:    old.Scopes,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}, now.Add(gracePeriod))
+	if err != nil {
+		return "", nil, fmt.Errorf("rotate api key: %w", err)
+	}
+	s.log.Infow("api key rotated", "apiKeyID", id, "newAPIKeyID", created.ID, "name", created.Name, "overlap", gracePeriod)
+	return key, created, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.storage.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey отзывает ключ сразу, следующий запрос с ним получит 401
+func (s *APIKeyService) RevokeKey(ctx context.Context, id int64) error {
+	if err := s.storage.RevokeAPIKey(ctx, id, time.Now().UTC()); err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	s.log.Infow("api key revoked", "apiKeyID", id)
+	return nil
+}
+
+// ValidateAPIKeyScopes проверяет, что scope'ы непустые и есть в каталоге
+func ValidateAPIKeyScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyRequest)
+	}
+	known := models.APIKeyScopes()
+	for _, scope := range scopes {
+		if !slices.Contains(known, scope) {
+			return fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKeyRequest, scope)
+		}
+	}
+	return nil
+}
+
+func validateAPIKeyRequest(name string, scopes []string, expiresAt *time.Time, now time.Time) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAPIKeyRequest)
+	}
+	if len(name) > maxAPIKeyNameLen {
+		return fmt.Errorf("%w: name must be at most %d characters", ErrInvalidAPIKeyRequest, maxAPIKeyNameLen)
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKeyRequest)
+	}
+	return ValidateAPIKeyScopes(scopes)
+}
+
+// generateAPIKey возвращает ключ "ak_<prefix>_<secret>" и его prefix.
+// prefix не секретный: по нему ключ находится в БД и узнается в логах.
+func generateAPIKey() (key, prefix string, err error) {
+	raw := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	prefix = hex.EncodeToString(raw[:apiKeyPrefixBytes])
+	secret := base64.RawURLEncoding.EncodeToString(raw[apiKeyPrefixBytes:])
+	return models.APIKeyPrefix + "_" + prefix + "_" + secret, prefix, nil
+}
+
+func parseAPIKeyPrefix(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != models.APIKeyPrefix || len(parts[1]) != 2*apiKeyPrefixBytes || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// SetAPIKey заменяет ключ из конфигурации и синхронизирует его в Redis:
+// прежний ключ, как и при перезапуске, принимается еще RotationOverlap
+func (s *APIKeyService) SetAPIKey(ctx context.Context, cfg *util.APIKeyConfig) error {
+	s.cfg.Store(cfg)
+	return s.SyncAPIKey(ctx)
 }
 
 func (s *APIKeyService) SyncAPIKey(ctx context.Context) error {
-	newKey := os.Getenv("AUTH_SERVICE_API_KEY")
+	cfg := s.cfg.Load()
+	newKey := cfg.Key
 	if newKey == "" {
 		return errors.New("AUTH_SERVICE_API_KEY is empty during sync attempt")
 	}
@@ -54,7 +294,11 @@ func (s *APIKeyService) SyncAPIKey(ctx context.Context) error {
 	}
 
 	pipe := s.rdb.Pipeline()
-	pipe.Set(ctx, OldAPIKeyRedisKey, currentHashedKey, defaultAPIKeyRedisTTL)
+	if cfg.RotationOverlap > 0 {
+		pipe.Set(ctx, OldAPIKeyRedisKey, currentHashedKey, cfg.RotationOverlap)
+	} else {
+		pipe.Del(ctx, OldAPIKeyRedisKey)
+	}
 	pipe.Set(ctx, CurrentAPIKeyRedisKey, hashedNewKey, 0)
 	pipe.Set(ctx, APIKeyRotationTimeRedisKey, time.Now().UTC().Format(time.RFC3339), 0)
 	_, err = pipe.Exec(ctx)
@@ -66,7 +310,7 @@ func (s *APIKeyService) SyncAPIKey(ctx context.Context) error {
 	return nil
 }
 
-// IsValidAPIKey rotation is 24-hour
+// IsValidAPIKey сверяет ключ с общим ключом; прежний ключ принимается RotationOverlap после смены
 func (s *APIKeyService) IsValidAPIKey(ctx context.Context, key string) (bool, error) {
 	if key == "" {
 		return false, nil
@@ -100,7 +344,7 @@ func (s *APIKeyService) IsValidAPIKey(ctx context.Context, key string) (bool, er
 			return false, fmt.Errorf("failed to parse key rotation time: %w", err)
 		}
 
-		if time.Since(rotationTime) <= 24*time.Hour {
+		if time.Since(rotationTime) <= s.cfg.Load().RotationOverlap {
 			return true, nil
 		}
 	}
@@ -109,9 +353,9 @@ func (s *APIKeyService) IsValidAPIKey(ctx context.Context, key string) (bool, er
 }
 
 func (s *APIKeyService) setInitialAPIKey(ctx context.Context) error {
-	apiKey := os.Getenv("AUTH_SERVICE_API_KEY")
+	apiKey := s.cfg.Load().Key
 	if apiKey == "" {
-		return errors.New("AUTH_SERVICE_API_KEY environment variable not set")
+		return errors.New("AUTH_SERVICE_API_KEY is not set")
 	}
 
 	hashedKey := s.hashAPIKey(apiKey)
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+const (
+	// auditBatchSize сколько записей читать за запрос при проверке цепочки и выгрузке и связывать за транзакцию
+	auditBatchSize = 1000
+	// auditLinkInterval как часто связывать новые записи в цепочку
+	auditLinkInterval = time.Second
+)
+
+var (
+	ErrAuditChainBroken   = errors.New("audit chain is broken")
+	ErrInvalidAuditCursor = errors.New("invalid audit cursor")
+	ErrInvalidAuditFilter = errors.New("invalid audit filter")
+)
+
+// AuditService ведет журнал аудита выдачи и отзыва токенов.
+// Записи пишутся в транзакциях IssueTokensTx/RotateTokensTx/RevokeSessionsTx вместе с изменением сессий,
+// в цепочку хэшей их связывает Run.
+type AuditService struct {
+	storage storage.Storage
+	log     *zap.SugaredLogger
+}
+
+func NewAuditService(s storage.Storage, log *zap.SugaredLogger) *AuditService {
+	return &AuditService{
+		storage: s,
+		log:     log,
+	}
+}
+
+// newAuditEvent запись об успешном действии клиента с метаданными запроса
+func newAuditEvent(eventType, actor string, userID int64, userMetadata models.UserMetadata) models.AuditEvent {
+	event := models.AuditEvent{
+		OccurredAt: time.Now().UTC(),
+		EventType:  eventType,
+		Actor:      actor,
+		IPAddress:  userMetadata.IPAddress,
+		UserAgent:  userMetadata.UserAgent,
+		Outcome:    models.AuditOutcomeSuccess,
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+	return event
+}
+
+// apiKeyActor actor аудита для вызова по API-ключу: имя ключа берется из контекста запроса
+func apiKeyActor(ctx context.Context) string {
+	if identity, ok := models.APIKeyFromContext(ctx); ok {
+		return models.AuditActorAPIKeyNamed(identity.Name)
+	}
+	return models.AuditActorAPIKey
+}
+
+// Run связывает новые записи в цепочку по таймеру до отмены ctx.
+// Запускается на каждой реплике, связывает та, что взяла блокировку цепочки в БД.
+func (s *AuditService) Run(ctx context.Context) {
+	ticker := time.NewTicker(auditLinkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.linkEvents(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				s.log.Errorw("audit chain linking failed", "error", err)
+			}
+		}
+	}
+}
+
+func (s *AuditService) linkEvents(ctx context.Context) error {
+	for {
+		linked, err := s.storage.LinkAuditEventsTx(ctx, auditBatchSize)
+		if err != nil {
+			return fmt.Errorf("link audit events: %w", err)
+		}
+		if linked < auditBatchSize {
+			return nil
+		}
+	}
+}
+
+// VerifyChain пересчитывает хэши связанных записей, сверяет ссылки на предыдущую запись
+// и последнюю запись с audit_chain_head: так обнаруживается и удаление записей с конца.
+// Записи, еще не связанные в цепочку, не проверяются.
+// Возвращает число проверенных записей и последнюю запись;
+// при расхождении - ErrAuditChainBroken с id первой плохой записи.
+func (s *AuditService) VerifyChain(ctx context.Context) (int64, models.AuditChainHead, error) {
+	// Голова читается до цепочки: записи, связанные во время проверки, не считаются лишними
+	head, err := s.storage.GetAuditChainHead(ctx)
+	if err != nil {
+		return 0, head, fmt.Errorf("get audit chain head: %w", err)
+	}
+
+	var (
+		last     models.AuditChainHead
+		prevHash = models.GenesisAuditHash()
+	)
+	for last.ChainSeq < head.ChainSeq {
+		events, err := s.storage.ListAuditChain(ctx, last.ChainSeq, auditBatchSize)
+		if err != nil {
+			return last.ChainSeq, last, fmt.Errorf("list audit events: %w", err)
+		}
+		for _, event := range events {
+			if event.ChainSeq != last.ChainSeq+1 || !bytes.Equal(event.PrevHash, prevHash) {
+				return last.ChainSeq, last, fmt.Errorf("%w: event %d does not link to the previous event",
+					ErrAuditChainBroken, event.ID)
+			}
+			hash, err := event.ChainHash(event.PrevHash)
+			if err != nil {
+				return last.ChainSeq, last, err
+			}
+			if !bytes.Equal(event.Hash, hash) {
+				return last.ChainSeq, last, fmt.Errorf("%w: event %d has been modified", ErrAuditChainBroken, event.ID)
+			}
+			prevHash = event.Hash
+			last = models.AuditChainHead{ChainSeq: event.ChainSeq, EventID: event.ID, Hash: event.Hash}
+			if last.ChainSeq == head.ChainSeq {
+				break
+			}
+		}
+		if len(events) < auditBatchSize {
+			break
+		}
+	}
+
+	if last.ChainSeq != head.ChainSeq || last.EventID != head.EventID || !bytes.Equal(last.Hash, head.Hash) {
+		return last.ChainSeq, last, fmt.Errorf("%w: chain ends at event %d, expected event %d",
+			ErrAuditChainBroken, last.EventID, head.EventID)
+	}
+	return last.ChainSeq, last, nil
+}
+
+// ListEvents страница журнала, новые записи первыми.
+// nextCursor пустой, если записей больше нет.
+func (s *AuditService) ListEvents(
+	ctx context.Context,
+	filter models.AuditFilter,
+	cursor string,
+	limit int,
+) (events []models.AuditEvent, nextCursor string, err error) {
+	if filter, err = normalizeAuditFilter(filter); err != nil {
+		return nil, "", err
+	}
+	if cursor != "" {
+		if filter.BeforeID, err = decodeAuditCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	events, err = s.storage.ListAuditEvents(ctx, filter, limit+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("list audit events: %w", err)
+	}
+	if len(events) > limit {
+		events = events[:limit]
+		nextCursor = encodeAuditCursor(events[limit-1].ID)
+	}
+	return events, nextCursor, nil
+}
+
+// ExportEvents передает в write все записи по фильтру в порядке id, включая еще не связанные в цепочку.
+// Журнал читается пачками, поэтому выгрузка не держит долгую транзакцию и не копит записи в памяти.
+func (s *AuditService) ExportEvents(
+	ctx context.Context,
+	filter models.AuditFilter,
+	write func(events []models.AuditEvent) error,
+) error {
+	filter, err := normalizeAuditFilter(filter)
+	if err != nil {
+		return err
+	}
+	filter.Ascending = true
+
+	for {
+		events, err := s.storage.ListAuditEvents(ctx, filter, auditBatchSize)
+		if err != nil {
+			return fmt.Errorf("list audit events: %w", err)
+		}
+		if len(events) > 0 {
+			if err := write(events); err != nil {
+				return err
+			}
+			filter.AfterID = events[len(events)-1].ID
+		}
+		if len(events) < auditBatchSize {
+			return nil
+		}
+	}
+}
+
+func normalizeAuditFilter(filter models.AuditFilter) (models.AuditFilter, error) {
+	if filter.IPAddress != "" {
+		addr, err := netip.ParseAddr(filter.IPAddress)
+		if err != nil {
+			return filter, fmt.Errorf("%w: ip %q", ErrInvalidAuditFilter, filter.IPAddress)
+		}
+		filter.IPAddress = addr.String()
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, fmt.Errorf("%w: from must be before to", ErrInvalidAuditFilter)
+	}
+	return filter, nil
+}
+
+// encodeAuditCursor курсор непрозрачен для клиента: внутри id последней записи страницы
+func encodeAuditCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeAuditCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidAuditCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidAuditCursor
+	}
+	return id, nil
+}
@@ -10,23 +10,42 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"github.com/rryowa/medods_dvortsov/internal/metrics"
 	"github.com/rryowa/medods_dvortsov/internal/models"
 	"github.com/rryowa/medods_dvortsov/internal/storage"
 	"github.com/rryowa/medods_dvortsov/internal/util"
 )
 
+const (
+	concurrentRotationAttempts = 5
+	concurrentRotationBackoff  = 50 * time.Millisecond
+)
+
+var ErrTokenReuseDetected = errors.New("refresh token reuse detected")
+
 type AuthService struct {
 	tokenService   *TokenService
 	storage        storage.Storage
 	webhookService *WebhookService
+	metrics        *metrics.Metrics
+	sessionConfig  *util.SessionConfig
 	log            *zap.SugaredLogger
 }
 
-func NewAuthService(ts *TokenService, s storage.Storage, ws *WebhookService, log *zap.SugaredLogger) *AuthService {
+func NewAuthService(
+	ts *TokenService,
+	s storage.Storage,
+	ws *WebhookService,
+	m *metrics.Metrics,
+	sc *util.SessionConfig,
+	log *zap.SugaredLogger,
+) *AuthService {
 	return &AuthService{
 		tokenService:   ts,
 		storage:        s,
 		webhookService: ws,
+		metrics:        m,
+		sessionConfig:  sc,
 		log:            log,
 	}
 }
@@ -47,6 +66,7 @@ func (as *AuthService) IssueTokens(
 	guid string,
 	userMetadata models.UserMetadata,
 ) (accessToken, refreshToken string, err error) {
+	defer func() { as.metrics.TokenOperation(metrics.OperationIssue, err) }()
 	as.log.Debugw("issuing tokens", "guid", guid)
 	now := time.Now().UTC()
 
@@ -63,11 +83,25 @@ func (as *AuthService) IssueTokens(
 		UserAgent:      userMetadata.UserAgent,
 		IPAddress:      userMetadata.IPAddress,
 		AccessTokenJTI: jti,
+		FamilyID:       uuid.NewString(),
 		CreatedAt:      now,
 		ExpiresAt:      now.Add(as.tokenService.refreshTTL),
 	}
 
-	user, err := as.storage.IssueTokensTx(ctx, guid, session)
+	audit := newAuditEvent(models.AuditTokenIssued, apiKeyActor(ctx), 0, userMetadata)
+	user, err := as.storage.IssueTokensTx(ctx, guid, session, func(user *models.User) ([]models.OutboxEvent, error) {
+		event, err := as.webhookService.NewEvent(ctx, models.SessionCreatedV1{
+			UserID:    user.ID,
+			GUID:      user.GUID,
+			FamilyID:  session.FamilyID,
+			IPAddress: userMetadata.IPAddress,
+			UserAgent: userMetadata.UserAgent,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []models.OutboxEvent{event}, nil
+	}, audit)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to execute issue tokens transaction: %w", err)
 	}
@@ -82,29 +116,93 @@ func (as *AuthService) IssueTokens(
 	return accessToken, refreshToken, nil
 }
 
-func (as *AuthService) detectTheftAndRevoke(ctx context.Context, selector string) error {
+// detectTheftAndRevoke вызывается, когда refresh-токен не найден среди активных.
+// Если сессия с таким selector уже использована - токен украден (или клиент ведет себя некорректно):
+// отзываем семейство этого логина или все сессии пользователя, в зависимости от REFRESH_REUSE_REVOKE_SCOPE.
+func (as *AuthService) detectTheftAndRevoke(
+	ctx context.Context,
+	selector string,
+	userMetadata models.UserMetadata,
+) error {
 	usedSession, err := as.storage.FindSessionBySelector(ctx, selector)
 	if err != nil {
 		// Сессия не найдена - невалиден, а не украден
 		return storage.ErrSessionNotFound
 	}
+	if usedSession.Status != models.SessionStatusUsed {
+		// Активная, но истекшая сессия - просто невалидный токен
+		return storage.ErrSessionNotFound
+	}
 
-	as.log.Warnw("token reuse detected, all sessions revoked", "selector", selector, "userID", usedSession.UserID)
-	if err := as.storage.DeleteAllUserSessions(ctx, usedSession.UserID); err != nil {
+	target, scope := as.revokeScope(usedSession)
+	audit := newAuditEvent(models.AuditTokenReuseDetected, models.AuditActorSystem, usedSession.UserID, userMetadata)
+	audit.SessionID = &usedSession.ID
+	audit.Outcome = models.AuditOutcomeFailure
+	audit.Reason = fmt.Sprintf("used refresh token presented, revoked %s sessions", scope)
+	_, err = as.storage.RevokeSessionsTx(ctx, target, as.eventsOf(ctx, true, models.TokenReuseDetectedV1{
+		UserID:    usedSession.UserID,
+		FamilyID:  usedSession.FamilyID,
+		Scope:     scope,
+		IPAddress: userMetadata.IPAddress,
+		UserAgent: userMetadata.UserAgent,
+	}), &audit)
+	if err != nil {
 		return fmt.Errorf("failed to revoke sessions after theft detection: %w", err)
 	}
 
-	return errors.New("token reuse detected, all sessions revoked")
+	as.metrics.TheftDetections.WithLabelValues(metrics.TheftTokenReuse).Inc()
+	as.log.Warnw("token reuse detected, sessions revoked",
+		"selector", selector,
+		"userID", usedSession.UserID,
+		"familyID", usedSession.FamilyID,
+		"scope", scope,
+	)
+
+	return fmt.Errorf("%w: revoked %s sessions (family %s)", ErrTokenReuseDetected, scope, usedSession.FamilyID)
+}
+
+// revokeScope сессии, которые отзываются в радиусе REFRESH_REUSE_REVOKE_SCOPE: семейство session
+// или все сессии пользователя. Возвращает цель отзыва и примененный радиус.
+func (as *AuthService) revokeScope(session *models.RefreshSession) (models.RevokeTarget, string) {
+	if as.sessionConfig.ReuseRevokeScope == models.RevokeScopeUser {
+		return models.RevokeTarget{UserID: session.UserID}, models.RevokeScopeUser
+	}
+	return models.RevokeTarget{FamilyID: session.FamilyID}, models.RevokeScopeFamily
+}
+
+// eventsOf строит события outbox для RevokeSessionsTx. При always = false события пишутся,
+// только если удалена хотя бы одна сессия
+func (as *AuthService) eventsOf(
+	ctx context.Context,
+	always bool,
+	data ...models.EventData,
+) func(result models.RevokeResult) ([]models.OutboxEvent, error) {
+	return func(result models.RevokeResult) ([]models.OutboxEvent, error) {
+		if !always && result.Deleted == 0 {
+			return nil, nil
+		}
+		events := make([]models.OutboxEvent, 0, len(data))
+		for _, d := range data {
+			event, err := as.webhookService.NewEvent(ctx, d)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		return events, nil
+	}
 }
 
 // RefreshTokens обновляет пару токенов
 // старый refresh-токен помечается как использованный - при попытке повторного
-// использования отзываются все сессии пользователя.
+// использования отзывается его семейство (или все сессии пользователя, см. detectTheftAndRevoke).
 func (as *AuthService) RefreshTokens(
 	ctx context.Context,
 	accessToken, refreshToken string,
 	userMetadata models.UserMetadata,
 ) (newAccessToken, newRefreshToken string, err error) {
+	defer func() { as.metrics.TokenOperation(metrics.OperationRefresh, err) }()
+
 	// Парсим access, чтобы получить JTI
 	claims, err := as.tokenService.getClaimsFromToken(accessToken)
 	if err != nil {
@@ -120,7 +218,11 @@ func (as *AuthService) RefreshTokens(
 	activeSession, err := as.storage.GetActiveSessionBySelector(ctx, selector)
 	if err != nil {
 		if errors.Is(err, storage.ErrSessionNotFound) {
-			return "", "", as.detectTheftAndRevoke(ctx, selector)
+			// Ретрай клиента в пределах grace window - отдаем уже выданную пару
+			if pair, ok := as.replayRotation(ctx, claims.ID, refreshToken, userMetadata); ok {
+				return pair.AccessToken, pair.RefreshToken, nil
+			}
+			return "", "", as.detectTheftAndRevoke(ctx, selector, userMetadata)
 		}
 		return "", "", fmt.Errorf("failed to get active session: %w", err)
 	}
@@ -130,27 +232,68 @@ func (as *AuthService) RefreshTokens(
 
 	// Проверка User-Agent
 	if activeSession.UserAgent != userMetadata.UserAgent {
-		as.log.Warnw("user-agent mismatch, revoking all sessions", "sessionID", activeSession.ID)
-		if err := as.storage.DeleteAllUserSessions(ctx, activeSession.UserID); err != nil {
+		target, scope := as.revokeScope(activeSession)
+		audit := newAuditEvent(models.AuditUserAgentMismatch, models.AuditActorSystem, activeSession.UserID, userMetadata)
+		audit.SessionID = &activeSession.ID
+		audit.Outcome = models.AuditOutcomeFailure
+		audit.Reason = fmt.Sprintf("expected user agent %q, revoked %s sessions", activeSession.UserAgent, scope)
+		_, err := as.storage.RevokeSessionsTx(ctx, target, as.eventsOf(ctx, true, models.UserAgentMismatchV1{
+			UserID:            activeSession.UserID,
+			FamilyID:          activeSession.FamilyID,
+			Scope:             scope,
+			ExpectedUserAgent: activeSession.UserAgent,
+			ActualUserAgent:   userMetadata.UserAgent,
+			IPAddress:         userMetadata.IPAddress,
+		}), &audit)
+		if err != nil {
 			return "", "", fmt.Errorf("failed to revoke sessions after user-agent mismatch: %w", err)
 		}
-		return "", "", errors.New("user-agent has changed, all sessions revoked")
+		as.log.Warnw("user-agent mismatch, sessions revoked",
+			"sessionID", activeSession.ID,
+			"familyID", activeSession.FamilyID,
+			"scope", scope,
+		)
+		as.metrics.TheftDetections.WithLabelValues(metrics.TheftUserAgentMismatch).Inc()
+		return "", "", fmt.Errorf("user-agent has changed, %s sessions revoked", scope)
+	}
+
+	if err := as.tokenService.ValidateRefreshToken(refreshToken, activeSession.VerifierHash); err != nil {
+		return "", "", err
+	}
+
+	refreshedEvent, err := as.webhookService.NewEvent(ctx, models.SessionRefreshedV1{
+		UserID:    activeSession.UserID,
+		FamilyID:  activeSession.FamilyID,
+		IPAddress: userMetadata.IPAddress,
+		UserAgent: userMetadata.UserAgent,
+	})
+	if err != nil {
+		return "", "", err
 	}
+	events := []models.OutboxEvent{refreshedEvent}
+	audit := newAuditEvent(
+		models.AuditTokenRefreshed,
+		models.AuditActorUser(activeSession.UserID),
+		activeSession.UserID,
+		userMetadata,
+	)
 
 	// Проверка ip
 	//TODO: comment condition to test webhook
 	if activeSession.IPAddress != userMetadata.IPAddress {
-		as.log.Infow("ip address changed, sending webhook notification", "sessionID", activeSession.ID)
-		as.webhookService.NotifyIPChange(ctx, map[string]any{
-			"user_id":    activeSession.UserID,
-			"old_ip":     activeSession.IPAddress,
-			"new_ip":     userMetadata.IPAddress,
-			"user_agent": userMetadata.UserAgent,
+		as.log.Infow("ip address changed, enqueueing webhook notification", "sessionID", activeSession.ID)
+		ipEvent, err := as.webhookService.NewEvent(ctx, models.IPChangedV1{
+			UserID:    activeSession.UserID,
+			FamilyID:  activeSession.FamilyID,
+			OldIP:     activeSession.IPAddress,
+			NewIP:     userMetadata.IPAddress,
+			UserAgent: userMetadata.UserAgent,
 		})
-	}
-
-	if err := as.tokenService.ValidateRefreshToken(refreshToken, activeSession.VerifierHash); err != nil {
-		return "", "", err
+		if err != nil {
+			return "", "", err
+		}
+		events = append(events, ipEvent)
+		audit.Reason = "ip address changed from " + activeSession.IPAddress
 	}
 
 	// Rotation
@@ -171,20 +314,148 @@ func (as *AuthService) RefreshTokens(
 		UserAgent:      userMetadata.UserAgent,
 		IPAddress:      userMetadata.IPAddress,
 		AccessTokenJTI: newJTI,
-		CreatedAt:      now,
+		FamilyID:       activeSession.FamilyID,
+		CreatedAt:      activeSession.CreatedAt,
 		ExpiresAt:      now.Add(as.tokenService.refreshTTL),
+		RefreshedAt:    &now,
 	}
 
-	_, err = as.storage.RotateTokensTx(ctx, selector, newSession, activeSession.UserID)
+	_, err = as.storage.RotateTokensTx(ctx, selector, newSession, activeSession.UserID, events, audit)
 	if err != nil {
+		if errors.Is(err, storage.ErrSessionAlreadyUsed) {
+			// Параллельный запрос с тем же refresh-токеном успел первым - это не кража
+			if pair, ok := as.awaitRotation(ctx, claims.ID, refreshToken, userMetadata); ok {
+				return pair.AccessToken, pair.RefreshToken, nil
+			}
+			return "", "", fmt.Errorf("concurrent refresh: %w", err)
+		}
 		return "", "", fmt.Errorf("failed to execute rotate tokens transaction: %w", err)
 	}
 
+	as.saveRotation(ctx, refreshToken, rotationResult{
+		AccessToken:       newAccessToken,
+		RefreshToken:      newRefreshToken,
+		OldAccessTokenJTI: claims.ID,
+		UserAgent:         userMetadata.UserAgent,
+	})
+
 	return newAccessToken, newRefreshToken, nil
 }
 
-// Logout отзывает access-токен и удаляет все refresh-сессии пользователя.
-func (as *AuthService) Logout(ctx context.Context, accessToken string) error {
+// saveRotation запоминает выданную пару на grace window. Ошибка не фатальна:
+// без сохраненной пары ретрай клиента будет обработан как повторное использование.
+func (as *AuthService) saveRotation(ctx context.Context, oldRefreshToken string, result rotationResult) {
+	grace := as.sessionConfig.ReuseGraceWindow
+	if grace <= 0 {
+		return
+	}
+
+	sealed, err := as.tokenService.sealRotationResult(oldRefreshToken, result)
+	if err != nil {
+		as.log.Errorw("failed to seal rotation result", "error", err)
+		return
+	}
+
+	selector, _, _ := strings.Cut(oldRefreshToken, ".")
+	if err := as.tokenService.tokenStorage.SaveRotationResult(ctx, selector, sealed, grace); err != nil {
+		as.log.Errorw("failed to save rotation result", "error", err)
+	}
+}
+
+// replayRotation возвращает пару, выданную при ротации refreshToken, если grace window еще не истек
+// и повтор пришел с тем же access-токеном и User-Agent.
+func (as *AuthService) replayRotation(
+	ctx context.Context,
+	accessTokenJTI, refreshToken string,
+	userMetadata models.UserMetadata,
+) (*rotationResult, bool) {
+	if as.sessionConfig.ReuseGraceWindow <= 0 {
+		return nil, false
+	}
+
+	selector, _, _ := strings.Cut(refreshToken, ".")
+	sealed, err := as.tokenService.tokenStorage.GetRotationResult(ctx, selector)
+	if err != nil {
+		as.log.Errorw("failed to get rotation result", "error", err)
+		return nil, false
+	}
+	if sealed == nil {
+		return nil, false
+	}
+
+	// Расшифровать может только владелец verifier старого токена
+	result, err := as.tokenService.openRotationResult(refreshToken, sealed)
+	if err != nil {
+		return nil, false
+	}
+	if result.OldAccessTokenJTI != accessTokenJTI || result.UserAgent != userMetadata.UserAgent {
+		return nil, false
+	}
+
+	as.log.Infow("refresh token replayed within grace window, returning issued pair", "selector", selector)
+	return result, true
+}
+
+// awaitRotation ждет, пока параллельный запрос сохранит результат ротации
+func (as *AuthService) awaitRotation(
+	ctx context.Context,
+	accessTokenJTI, refreshToken string,
+	userMetadata models.UserMetadata,
+) (*rotationResult, bool) {
+	for range concurrentRotationAttempts {
+		if result, ok := as.replayRotation(ctx, accessTokenJTI, refreshToken, userMetadata); ok {
+			return result, true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(concurrentRotationBackoff):
+		}
+	}
+	return nil, false
+}
+
+// Logout отзывает access-токен и удаляет только его refresh-сессию:
+// выход на одном устройстве не затрагивает остальные.
+func (as *AuthService) Logout(
+	ctx context.Context,
+	accessToken string,
+	userMetadata models.UserMetadata,
+) (err error) {
+	defer func() { as.metrics.TokenOperation(metrics.OperationLogout, err) }()
+
+	userID, err := as.tokenService.ValidateAccessTokenAndGetUserID(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("access token validation failed: %w", err)
+	}
+
+	claims, err := as.tokenService.getClaimsFromToken(accessToken)
+	if err != nil {
+		return fmt.Errorf("get claims from token: %w", err)
+	}
+
+	if err := as.tokenService.InvalidateAccessToken(ctx, accessToken); err != nil {
+		return fmt.Errorf("failed to invalidate access token: %w", err)
+	}
+
+	audit := newAuditEvent(models.AuditLogout, models.AuditActorUser(userID), userID, userMetadata)
+	_, err = as.storage.RevokeSessionsTx(ctx, models.RevokeTarget{AccessTokenJTI: claims.ID},
+		as.eventsOf(ctx, true, models.LogoutV1{UserID: userID}), &audit)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// LogoutAll отзывает access-токен и удаляет все refresh-сессии пользователя ("выйти везде").
+func (as *AuthService) LogoutAll(
+	ctx context.Context,
+	accessToken string,
+	userMetadata models.UserMetadata,
+) (err error) {
+	defer func() { as.metrics.TokenOperation(metrics.OperationLogoutAll, err) }()
+
 	userID, err := as.tokenService.ValidateAccessTokenAndGetUserID(ctx, accessToken)
 	if err != nil {
 		return fmt.Errorf("access token validation failed: %w", err)
@@ -194,13 +465,268 @@ func (as *AuthService) Logout(ctx context.Context, accessToken string) error {
 		return fmt.Errorf("failed to invalidate access token: %w", err)
 	}
 
-	if err := as.storage.DeleteAllUserSessions(ctx, userID); err != nil {
+	audit := newAuditEvent(models.AuditLogoutAll, models.AuditActorUser(userID), userID, userMetadata)
+	_, err = as.storage.RevokeSessionsTx(ctx, models.RevokeTarget{UserID: userID},
+		as.eventsOf(ctx, true, models.LogoutV1{UserID: userID, AllDevices: true}), &audit)
+	if err != nil {
 		return fmt.Errorf("failed to delete all user sessions: %w", err)
 	}
 
 	return nil
 }
 
+// RevokeToken отзывает один токен (RFC 7009) вместе с его сессией.
+// Access-токен попадает в denylist, refresh-сессия удаляется; для refresh-токена
+// в denylist попадает связанный с сессией access-токен.
+// Невалидный или уже отозванный токен - не ошибка (RFC 7009, 2.2).
+func (as *AuthService) RevokeToken(
+	ctx context.Context,
+	token string,
+	userMetadata models.UserMetadata,
+) (err error) {
+	defer func() { as.metrics.TokenOperation(metrics.OperationRevoke, err) }()
+
+	if strings.Count(token, ".") == util.TokenPartsExpected-1 {
+		return as.revokeRefreshToken(ctx, token, userMetadata)
+	}
+	return as.revokeAccessToken(ctx, token, userMetadata)
+}
+
+func (as *AuthService) revokeAccessToken(ctx context.Context, token string, userMetadata models.UserMetadata) error {
+	claims, err := as.tokenService.parseAccessToken(token)
+	if err != nil {
+		as.log.Debugw("revocation of invalid access token ignored", "error", err)
+		return nil
+	}
+
+	if err := as.tokenService.InvalidateAccessToken(ctx, token); err != nil {
+		return fmt.Errorf("failed to invalidate access token: %w", err)
+	}
+	// Без пользователя в claims событие и запись аудита не о ком писать
+	userID, userErr := claims.userID()
+	var audit *models.AuditEvent
+	if userErr == nil {
+		event := newAuditEvent(models.AuditTokenRevoked, apiKeyActor(ctx), userID, userMetadata)
+		event.Reason = "access token revoked"
+		audit = &event
+	}
+	events := func(result models.RevokeResult) ([]models.OutboxEvent, error) {
+		if userErr != nil || result.FamilyID == "" {
+			return nil, nil
+		}
+		return as.eventsOf(ctx, true, models.SessionRevokedV1{
+			UserID:   userID,
+			FamilyID: result.FamilyID,
+			Reason:   models.RevokeReasonRevocation,
+		})(result)
+	}
+	if _, err := as.storage.RevokeSessionsTx(ctx, models.RevokeTarget{AccessTokenJTI: claims.ID}, events, audit); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	as.log.Debugw("access token revoked", "jti", claims.ID)
+	return nil
+}
+
+func (as *AuthService) revokeRefreshToken(ctx context.Context, token string, userMetadata models.UserMetadata) error {
+	selector, _, _ := strings.Cut(token, ".")
+	session, err := as.storage.FindSessionBySelector(ctx, selector)
+	if err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return nil
+		}
+		return fmt.Errorf("find session by selector: %w", err)
+	}
+	if err := as.tokenService.ValidateRefreshToken(token, session.VerifierHash); err != nil {
+		as.log.Debugw("revocation of invalid refresh token ignored", "sessionID", session.ID)
+		return nil
+	}
+	// Использованный токен уже недействителен; его сессия нужна для обнаружения повторного использования
+	if session.Status != models.SessionStatusActive {
+		as.log.Debugw("revocation of used refresh token ignored", "sessionID", session.ID)
+		return nil
+	}
+
+	if err := as.tokenService.InvalidateAccessTokenJTI(ctx, session.AccessTokenJTI); err != nil {
+		return fmt.Errorf("failed to invalidate access token: %w", err)
+	}
+	audit := newAuditEvent(models.AuditTokenRevoked, apiKeyActor(ctx), session.UserID, userMetadata)
+	audit.SessionID = &session.ID
+	audit.Reason = "refresh token revoked"
+	_, err = as.storage.RevokeSessionsTx(ctx, models.RevokeTarget{Selector: selector},
+		as.eventsOf(ctx, false, models.SessionRevokedV1{
+			UserID:   session.UserID,
+			FamilyID: session.FamilyID,
+			Reason:   models.RevokeReasonRevocation,
+		}), &audit)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	as.log.Debugw("refresh token revoked", "sessionID", session.ID)
+	return nil
+}
+
+// IntrospectToken проверяет access- или refresh-токен (RFC 7662).
+// Невалидный токен - это Active: false, ошибка возвращается только при сбое хранилищ.
+// Тип определяется по формату токена (JWT или selector.verifier), поэтому token_type_hint не нужен.
+func (as *AuthService) IntrospectToken(ctx context.Context, token string) (models.TokenIntrospection, error) {
+	var (
+		result models.TokenIntrospection
+		err    error
+	)
+	if strings.Count(token, ".") == util.TokenPartsExpected-1 {
+		result, err = as.introspectRefreshToken(ctx, token)
+	} else {
+		result, err = as.introspectAccessToken(ctx, token)
+	}
+	if err != nil || !result.Active {
+		return models.TokenIntrospection{}, err
+	}
+
+	user, err := as.storage.GetUserByID(ctx, result.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.TokenIntrospection{}, nil
+		}
+		return models.TokenIntrospection{}, fmt.Errorf("get user by id: %w", err)
+	}
+	result.GUID = user.GUID
+
+	return result, nil
+}
+
+func (as *AuthService) introspectAccessToken(ctx context.Context, token string) (models.TokenIntrospection, error) {
+	isInvalidated, err := as.tokenService.IsAccessTokenInvalidated(ctx, token)
+	if err != nil {
+		return models.TokenIntrospection{}, fmt.Errorf("failed to check if token is invalidated: %w", err)
+	}
+	if isInvalidated {
+		return models.TokenIntrospection{}, nil
+	}
+
+	claims, err := as.tokenService.parseAccessToken(token)
+	if err != nil {
+		as.log.Debugw("introspected access token is not active", "error", err)
+		return models.TokenIntrospection{}, nil
+	}
+	userID, err := claims.userID()
+	if err != nil {
+		return models.TokenIntrospection{}, nil
+	}
+
+	return models.TokenIntrospection{
+		Active:    true,
+		TokenType: models.TokenTypeAccess,
+		UserID:    userID,
+		JTI:       claims.ID,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+func (as *AuthService) introspectRefreshToken(ctx context.Context, token string) (models.TokenIntrospection, error) {
+	selector, _, _ := strings.Cut(token, ".")
+	session, err := as.storage.FindSessionBySelector(ctx, selector)
+	if err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return models.TokenIntrospection{}, nil
+		}
+		return models.TokenIntrospection{}, fmt.Errorf("find session by selector: %w", err)
+	}
+
+	if session.Status != models.SessionStatusActive || !time.Now().Before(session.ExpiresAt) {
+		return models.TokenIntrospection{}, nil
+	}
+	if err := as.tokenService.ValidateRefreshToken(token, session.VerifierHash); err != nil {
+		return models.TokenIntrospection{}, nil
+	}
+
+	// refresh-токен выпущен при последней ротации
+	issuedAt := session.CreatedAt
+	if session.RefreshedAt != nil {
+		issuedAt = *session.RefreshedAt
+	}
+
+	return models.TokenIntrospection{
+		Active:    true,
+		TokenType: models.TokenTypeRefresh,
+		UserID:    session.UserID,
+		JTI:       session.Selector,
+		IssuedAt:  issuedAt,
+		ExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// ListSessions возвращает активные сессии пользователя ("где я залогинен").
+// Сессия, к которой привязан accessToken, помечается как текущая.
+func (as *AuthService) ListSessions(
+	ctx context.Context,
+	userID int64,
+	accessToken string,
+) ([]models.SessionInfo, error) {
+	var currentJTI string
+	if claims, err := as.tokenService.getClaimsFromToken(accessToken); err == nil {
+		currentJTI = claims.ID
+	}
+
+	sessions, err := as.storage.ListActiveUserSessions(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("list active user sessions: %w", err)
+	}
+
+	infos := make([]models.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		ua := util.ParseUserAgent(session.UserAgent)
+		infos = append(infos, models.SessionInfo{
+			ID:          session.ID,
+			IPAddress:   session.IPAddress,
+			UserAgent:   session.UserAgent,
+			Browser:     ua.Browser,
+			OS:          ua.OS,
+			DeviceType:  ua.DeviceType,
+			CreatedAt:   session.CreatedAt,
+			ExpiresAt:   session.ExpiresAt,
+			RefreshedAt: session.RefreshedAt,
+			Current:     currentJTI != "" && session.AccessTokenJTI == currentJTI,
+		})
+	}
+	return infos, nil
+}
+
+// RevokeSession завершает одну сессию пользователя (выход на конкретном устройстве).
+// Access-токен этой сессии попадает в denylist.
+func (as *AuthService) RevokeSession(
+	ctx context.Context,
+	userID, sessionID int64,
+	userMetadata models.UserMetadata,
+) (err error) {
+	defer func() { as.metrics.TokenOperation(metrics.OperationSessionRevoke, err) }()
+
+	session, err := as.storage.GetUserSessionByID(ctx, userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("get user session: %w", err)
+	}
+
+	if err := as.tokenService.InvalidateAccessTokenJTI(ctx, session.AccessTokenJTI); err != nil {
+		return fmt.Errorf("failed to invalidate access token: %w", err)
+	}
+	audit := newAuditEvent(models.AuditSessionRevoked, models.AuditActorUser(userID), userID, userMetadata)
+	audit.SessionID = &sessionID
+	_, err = as.storage.RevokeSessionsTx(ctx, models.RevokeTarget{UserID: userID, SessionID: sessionID},
+		as.eventsOf(ctx, true, models.SessionRevokedV1{
+			UserID:   userID,
+			FamilyID: session.FamilyID,
+			Reason:   models.RevokeReasonUser,
+		}), &audit)
+	if err != nil {
+		return fmt.Errorf("delete user session: %w", err)
+	}
+
+	as.log.Debugw("session revoked", "userID", userID, "sessionID", sessionID)
+	return nil
+}
+
 func (as *AuthService) GetPublicGUID(ctx context.Context, userID int64) (string, error) {
 	as.log.Debugw("getting public guid", "userID", userID)
 	user, err := as.storage.GetUserByID(ctx, userID)
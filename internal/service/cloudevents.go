@@ -0,0 +1,141 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsContentType = "application/cloudevents+json"
+	jsonContentType        = "application/json"
+
+	// Заголовки binary-режима (CloudEvents HTTP Protocol Binding, 3.1.3)
+	ceHeaderSpecVersion   = "ce-specversion"
+	ceHeaderID            = "ce-id"
+	ceHeaderSource        = "ce-source"
+	ceHeaderType          = "ce-type"
+	ceHeaderTime          = "ce-time"
+	ceHeaderSubject       = "ce-subject"
+	ceHeaderSchemaVersion = "ce-schemaversion"
+)
+
+var ErrUnknownWebhookFormat = errors.New("unknown webhook format")
+
+// storedEnvelope конверт из outbox: data остается сырым JSON
+type storedEnvelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// cloudEvent событие в structured-режиме.
+// schemaversion - extension-атрибут с версией схемы data (EventData.EventVersion).
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	Subject         string          `json:"subject,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	SchemaVersion   int             `json:"schemaversion"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// ValidateWebhookFormat проверяет формат доставки endpoint'а
+func ValidateWebhookFormat(format string) error {
+	switch format {
+	case models.WebhookFormatJSON, models.WebhookFormatCloudEventsStructured, models.WebhookFormatCloudEventsBinary:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownWebhookFormat, format)
+	}
+}
+
+// contentEventID выводит UUIDv5 из содержимого события. Время возникновения входит в имя,
+// поэтому два одинаковых по данным события получают разные ID. Стабильность между повторами
+// доставки обеспечивает outbox: ID вычисляется один раз при записи события.
+func contentEventID(source, eventType string, version int, occurredAt time.Time, data []byte) string {
+	namespace := uuid.NewSHA1(uuid.NameSpaceURL, []byte(source))
+	name := make([]byte, 0, len(eventType)+len(data)+64)
+	name = append(name, eventType...)
+	name = append(name, '\n')
+	name = strconv.AppendInt(name, int64(version), 10)
+	name = append(name, '\n')
+	name = occurredAt.UTC().AppendFormat(name, time.RFC3339Nano)
+	name = append(name, '\n')
+	name = append(name, data...)
+	return uuid.NewSHA1(namespace, name).String()
+}
+
+// encodeWebhookBody готовит тело и заголовки доставки в формате endpoint'а
+func (s *WebhookService) encodeWebhookBody(format string, payload []byte) ([]byte, http.Header, error) {
+	headers := http.Header{}
+	if format == models.WebhookFormatJSON || format == "" {
+		headers.Set("Content-Type", jsonContentType)
+		return payload, headers, nil
+	}
+
+	var envelope storedEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, nil, fmt.Errorf("decode stored event: %w", err)
+	}
+	subject := eventSubject(envelope.Data)
+
+	switch format {
+	case models.WebhookFormatCloudEventsStructured:
+		body, err := json.Marshal(cloudEvent{
+			SpecVersion:     cloudEventsSpecVersion,
+			ID:              envelope.ID,
+			Source:          s.cfg.Load().EventSource,
+			Type:            envelope.Type,
+			Time:            envelope.OccurredAt,
+			Subject:         subject,
+			DataContentType: jsonContentType,
+			SchemaVersion:   envelope.Version,
+			Data:            envelope.Data,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("encode cloud event: %w", err)
+		}
+		headers.Set("Content-Type", cloudEventsContentType)
+		return body, headers, nil
+	case models.WebhookFormatCloudEventsBinary:
+		// Атрибуты в заголовках, в теле только data
+		headers.Set("Content-Type", jsonContentType)
+		headers.Set(ceHeaderSpecVersion, cloudEventsSpecVersion)
+		headers.Set(ceHeaderID, envelope.ID)
+		headers.Set(ceHeaderSource, s.cfg.Load().EventSource)
+		headers.Set(ceHeaderType, envelope.Type)
+		headers.Set(ceHeaderTime, envelope.OccurredAt.Format(time.RFC3339Nano))
+		headers.Set(ceHeaderSchemaVersion, strconv.Itoa(envelope.Version))
+		if subject != "" {
+			headers.Set(ceHeaderSubject, subject)
+		}
+		return envelope.Data, headers, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownWebhookFormat, format)
+	}
+}
+
+// eventSubject subject события - пользователь, которого оно касается
+func eventSubject(data json.RawMessage) string {
+	var subject struct {
+		UserID *int64 `json:"user_id"`
+	}
+	if err := json.Unmarshal(data, &subject); err != nil || subject.UserID == nil {
+		return ""
+	}
+	return strconv.FormatInt(*subject.UserID, 10)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+)
+
+const (
+	// healthCheckTimeout ограничивает каждую проверку: зависшая зависимость - это тоже "не готов"
+	healthCheckTimeout = 2 * time.Second
+
+	shutdownCheckName = "shutdown"
+)
+
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// HealthCheck проверка зависимости: nil - зависимость доступна
+type HealthCheck func(ctx context.Context) error
+
+type namedHealthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// HealthService проверки готовности принимать трафик (/readyz).
+// С началом graceful shutdown готовность сразу становится отрицательной,
+// чтобы балансировщик перестал слать запросы, пока сервер дорабатывает текущие.
+type HealthService struct {
+	checks       []namedHealthCheck
+	shuttingDown atomic.Bool
+	log          *zap.SugaredLogger
+}
+
+func NewHealthService(log *zap.SugaredLogger) *HealthService {
+	return &HealthService{log: log}
+}
+
+// AddCheck регистрирует проверку. Вызывается при сборке сервиса, до начала обработки запросов.
+func (s *HealthService) AddCheck(name string, check HealthCheck) {
+	s.checks = append(s.checks, namedHealthCheck{name: name, check: check})
+}
+
+// SetShuttingDown переводит readiness в fail до конца работы процесса
+func (s *HealthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+// Readiness выполняет все проверки параллельно
+func (s *HealthService) Readiness(ctx context.Context) models.HealthReport {
+	report := models.HealthReport{
+		Status: models.HealthStatusOK,
+		Checks: make(map[string]models.HealthCheckResult, len(s.checks)+1),
+	}
+
+	shutdown := models.HealthCheckResult{Status: models.HealthStatusOK}
+	if s.shuttingDown.Load() {
+		shutdown = models.HealthCheckResult{Status: models.HealthStatusFail, Error: ErrShuttingDown.Error()}
+		report.Status = models.HealthStatusFail
+	}
+	report.Checks[shutdownCheckName] = shutdown
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := runHealthCheck(ctx, c.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status != models.HealthStatusOK {
+				report.Status = models.HealthStatusFail
+				s.log.Warnw("readiness check failed", "check", c.name, "error", result.Error)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func runHealthCheck(ctx context.Context, check HealthCheck) models.HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := models.HealthCheckResult{
+		Status:    models.HealthStatusOK,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = models.HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+var ErrNoActiveSigningKey = errors.New("no active signing key")
+
+// KeyRingService связка ключей подписи access-токенов.
+// Один текущий ключ подписывает, остальные (pending и retired) только проверяют по kid.
+// Связка хранится в Postgres, поэтому все реплики подписывают одним ключом
+// и принимают токены, подписанные любым ключом из связки.
+// Материал ключей хранится зашифрованным ключом JWT_KEY_ENCRYPTION_KEY.
+type KeyRingService struct {
+	storage storage.Storage
+	log     *zap.SugaredLogger
+	kek     cipher.AEAD
+
+	overlap          time.Duration
+	rotationInterval time.Duration
+	publishDelay     time.Duration
+	reloadInterval   time.Duration
+
+	mu sync.RWMutex
+	// algorithm и ключ из конфигурации меняются при перечитывании конфигурации (SIGHUP)
+	algorithm          string
+	configuredKey      *SigningKey
+	configuredMaterial []byte
+	keys               map[string]*SigningKey
+	current            *SigningKey
+	activatedAt        time.Time
+	hasPending         bool
+}
+
+func NewKeyRingService(cfg *util.TokenConfig, s storage.Storage, log *zap.SugaredLogger) (*KeyRingService, error) {
+	configuredKey, material, err := loadConfiguredKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := keyEncryptionCipher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Выведенный ключ должен жить не меньше access-токена, иначе токены,
+	// выпущенные прямо перед ротацией, станут невалидными раньше срока
+	overlap := cfg.KeyOverlap
+	if minOverlap := cfg.AccessTTL + util.JWTLeeWay; overlap < minOverlap {
+		log.Warnw("JWT_KEY_OVERLAP is shorter than access token TTL, using TTL instead",
+			"overlap", overlap, "accessTTL", cfg.AccessTTL)
+		overlap = minOverlap
+	}
+
+	return &KeyRingService{
+		storage:            s,
+		log:                log,
+		kek:                kek,
+		algorithm:          cfg.SigningAlg,
+		configuredKey:      configuredKey,
+		configuredMaterial: material,
+		overlap:            overlap,
+		rotationInterval:   cfg.KeyRotationInterval,
+		publishDelay:       cfg.KeyPublishDelay,
+		reloadInterval:     cfg.KeyReloadInterval,
+		keys:               map[string]*SigningKey{},
+	}, nil
+}
+
+func loadConfiguredKey(cfg *util.TokenConfig) (*SigningKey, []byte, error) {
+	material := []byte(cfg.PrivateKey)
+	if cfg.SigningAlg == AlgHS512 {
+		material = []byte(cfg.JWTSecret)
+	}
+
+	key, err := NewSigningKey(cfg.SigningAlg, material, cfg.KeyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load signing key: %w", err)
+	}
+	return key, material, nil
+}
+
+func keyEncryptionCipher(cfg *util.TokenConfig) (cipher.AEAD, error) {
+	key, err := cfg.EncryptionKey()
+	if err != nil {
+		return nil, fmt.Errorf("load key encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("new cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("new gcm: %w", err)
+	}
+	return gcm, nil
+}
+
+// seal шифрует материал ключа. kid - associated data: материал нельзя подставить в строку другого ключа
+func (s *KeyRingService) seal(kid string, material []byte) ([]byte, error) {
+	nonce := make([]byte, s.kek.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return s.kek.Seal(nonce, nonce, material, []byte(kid)), nil
+}
+
+// open расшифровывает материал ключа из БД. Строки, записанные до шифрования, возвращаются как есть
+func (s *KeyRingService) open(sk models.StoredSigningKey) ([]byte, error) {
+	if !sk.Encrypted {
+		return sk.KeyMaterial, nil
+	}
+	if len(sk.KeyMaterial) < s.kek.NonceSize() {
+		return nil, errors.New("encrypted key material is too short")
+	}
+	nonce, ciphertext := sk.KeyMaterial[:s.kek.NonceSize()], sk.KeyMaterial[s.kek.NonceSize():]
+	material, err := s.kek.Open(nil, nonce, ciphertext, []byte(sk.KID))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt key material: %w", err)
+	}
+	return material, nil
+}
+
+// SetConfiguredKey заменяет ключ из конфигурации (перечитывание по SIGHUP) и синхронизирует связку.
+// Если новый ключ не загружается, остается прежний.
+func (s *KeyRingService) SetConfiguredKey(ctx context.Context, cfg *util.TokenConfig) error {
+	key, material, err := loadConfiguredKey(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.algorithm = cfg.SigningAlg
+	s.configuredKey = key
+	s.configuredMaterial = material
+	s.mu.Unlock()
+
+	return s.SyncSigningKey(ctx)
+}
+
+// SyncSigningKey добавляет ключ из конфигурации в связку и загружает связку.
+// Работает как SyncAPIKey: если ключ из конфигурации новый, он становится текущим,
+// а прежний ключ продолжает проверять токены в течение overlap.
+func (s *KeyRingService) SyncSigningKey(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	s.mu.RLock()
+	algorithm, configuredKey, material := s.algorithm, s.configuredKey, s.configuredMaterial
+	s.mu.RUnlock()
+
+	sealed, err := s.seal(configuredKey.KID, material)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt configured signing key: %w", err)
+	}
+	created, err := s.storage.CreateSigningKey(ctx, models.StoredSigningKey{
+		KID:         configuredKey.KID,
+		Algorithm:   algorithm,
+		KeyMaterial: sealed,
+		Encrypted:   true,
+		Status:      models.SigningKeyStatusPending,
+		CreatedAt:   now,
+		ActivatesAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store configured signing key: %w", err)
+	}
+
+	if created {
+		if err := s.storage.PromoteSigningKeyTx(ctx, configuredKey.KID, now, now.Add(s.overlap)); err != nil {
+			return fmt.Errorf("failed to promote configured signing key: %w", err)
+		}
+		s.log.Infow("Signing key synced: configured key is now current", "kid", configuredKey.KID)
+	} else {
+		// Ключ уже в связке (в том числе выведенный) или удален после вывода:
+		// ключ из конфигурации не возвращается в ротацию, подписывает текущий ключ связки
+		tombstoned, err := s.storage.IsSigningKeyTombstoned(ctx, configuredKey.KID)
+		if err != nil {
+			return fmt.Errorf("failed to check configured signing key: %w", err)
+		}
+		if tombstoned {
+			s.log.Warnw("configured signing key was retired and removed, it is not used; configure a new key",
+				"kid", configuredKey.KID)
+		} else {
+			s.log.Info("Skipping signing key sync: configured key is already in the key ring.")
+		}
+	}
+
+	if err := s.encryptLegacyKeys(ctx); err != nil {
+		return err
+	}
+	return s.Reload(ctx)
+}
+
+// encryptLegacyKeys шифрует ключи, сохраненные в БД до появления шифрования
+func (s *KeyRingService) encryptLegacyKeys(ctx context.Context) error {
+	// Нулевое время - все ключи, включая выведенные и еще не удаленные
+	stored, err := s.storage.ListSigningKeys(ctx, time.Time{})
+	if err != nil {
+		return fmt.Errorf("list signing keys: %w", err)
+	}
+	for _, sk := range stored {
+		if sk.Encrypted {
+			continue
+		}
+		sealed, err := s.seal(sk.KID, sk.KeyMaterial)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt signing key %s: %w", sk.KID, err)
+		}
+		if err := s.storage.EncryptSigningKey(ctx, sk.KID, sealed); err != nil {
+			return err
+		}
+		s.log.Infow("signing key encrypted at rest", "kid", sk.KID)
+	}
+	return nil
+}
+
+// Reload перечитывает связку из БД
+func (s *KeyRingService) Reload(ctx context.Context) error {
+	stored, err := s.storage.ListSigningKeys(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("list signing keys: %w", err)
+	}
+
+	var (
+		keys        = make(map[string]*SigningKey, len(stored))
+		current     *SigningKey
+		activatedAt time.Time
+		hasPending  bool
+	)
+	for _, sk := range stored {
+		material, err := s.open(sk)
+		if err != nil {
+			s.log.Errorw("skipping undecryptable signing key", "kid", sk.KID, "error", err)
+			continue
+		}
+		key, err := NewSigningKey(sk.Algorithm, material, sk.KID)
+		if err != nil {
+			s.log.Errorw("skipping invalid signing key", "kid", sk.KID, "error", err)
+			continue
+		}
+		keys[sk.KID] = key
+		switch sk.Status {
+		case models.SigningKeyStatusActive:
+			current = key
+			activatedAt = sk.ActivatesAt
+		case models.SigningKeyStatusPending:
+			hasPending = true
+		}
+	}
+	if current == nil {
+		return ErrNoActiveSigningKey
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.current = current
+	s.activatedAt = activatedAt
+	s.hasPending = hasPending
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Run периодически выполняет запланированные ротации и перечитывает связку
+func (s *KeyRingService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.maintain(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				s.log.Errorw("key ring maintenance failed", "error", err)
+			}
+		}
+	}
+}
+
+// Current ключ, которым подписываются новые токены
+func (s *KeyRingService) Current() *SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// CheckSigningKey проверка готовности: связка загружена и есть ключ для подписи
+func (s *KeyRingService) CheckSigningKey(_ context.Context) error {
+	if s.Current() == nil {
+		return ErrNoActiveSigningKey
+	}
+	return nil
+}
+
+// Lookup ищет ключ проверки по kid
+func (s *KeyRingService) Lookup(kid string) (*SigningKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// JWKS публичные ключи всей связки: pending-ключ публикуется заранее,
+// retired - пока им подписаны живые токены
+func (s *KeyRingService) JWKS() []models.JWK {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := make([]models.JWK, 0, len(s.keys))
+	for _, key := range s.keys {
+		if jwk, ok := key.JWK(); ok {
+			jwks = append(jwks, jwk)
+		}
+	}
+	return jwks
+}
+
+// Algorithms алгоритмы, которые допустимы при проверке токенов
+func (s *KeyRingService) Algorithms() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]struct{}, len(s.keys))
+	algs := make([]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		alg := key.Method.Alg()
+		if _, ok := seen[alg]; !ok {
+			seen[alg] = struct{}{}
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+func (s *KeyRingService) maintain(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	if s.rotationInterval > 0 {
+		if err := s.scheduleRotation(ctx, now); err != nil {
+			return err
+		}
+	}
+
+	due, err := s.storage.ListDueSigningKeys(ctx, now)
+	if err != nil {
+		return fmt.Errorf("list due signing keys: %w", err)
+	}
+	for _, key := range due {
+		if err := s.storage.PromoteSigningKeyTx(ctx, key.KID, now, now.Add(s.overlap)); err != nil {
+			return fmt.Errorf("promote signing key %s: %w", key.KID, err)
+		}
+		s.log.Infow("signing key promoted", "kid", key.KID)
+	}
+
+	deleted, err := s.storage.DeleteRetiredSigningKeys(ctx, now)
+	if err != nil {
+		return fmt.Errorf("delete retired signing keys: %w", err)
+	}
+	if deleted > 0 {
+		s.log.Infow("retired signing keys removed", "count", deleted)
+	}
+
+	return s.Reload(ctx)
+}
+
+// scheduleRotation добавляет pending-ключ, если текущему ключу пора на покой.
+// Ключ сначала публикуется в JWKS и только через publishDelay начинает подписывать,
+// чтобы resource-серверы успели обновить кэш JWKS.
+func (s *KeyRingService) scheduleRotation(ctx context.Context, now time.Time) error {
+	s.mu.RLock()
+	due := !s.hasPending && now.Sub(s.activatedAt) >= s.rotationInterval
+	algorithm := s.algorithm
+	s.mu.RUnlock()
+	if !due {
+		return nil
+	}
+
+	material, err := GenerateKeyMaterial(algorithm)
+	if err != nil {
+		return fmt.Errorf("generate signing key: %w", err)
+	}
+	key, err := NewSigningKey(algorithm, material, "")
+	if err != nil {
+		return fmt.Errorf("load generated signing key: %w", err)
+	}
+
+	sealed, err := s.seal(key.KID, material)
+	if err != nil {
+		return fmt.Errorf("encrypt signing key: %w", err)
+	}
+
+	scheduled, err := s.storage.ScheduleSigningKeyTx(ctx, models.StoredSigningKey{
+		KID:         key.KID,
+		Algorithm:   algorithm,
+		KeyMaterial: sealed,
+		Encrypted:   true,
+		Status:      models.SigningKeyStatusPending,
+		CreatedAt:   now,
+		ActivatesAt: now.Add(s.publishDelay),
+	}, now.Add(-s.rotationInterval))
+	if err != nil {
+		return fmt.Errorf("schedule signing key: %w", err)
+	}
+	if scheduled {
+		s.log.Infow("signing key rotation scheduled", "kid", key.KID, "activatesAt", now.Add(s.publishDelay))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+const sessionReaperLockKey = "session_reaper"
+
+// SessionReaper периодически удаляет истекшие и давно использованные сессии.
+// Между репликами работа не дублируется: проход выполняет тот, кто взял блокировку в Redis.
+type SessionReaper struct {
+	storage storage.Storage
+	locker  storage.Locker
+	log     *zap.SugaredLogger
+
+	interval  time.Duration
+	retention time.Duration
+	batchSize int
+}
+
+func NewSessionReaper(
+	s storage.Storage,
+	locker storage.Locker,
+	sc *util.SessionConfig,
+	log *zap.SugaredLogger,
+) *SessionReaper {
+	return &SessionReaper{
+		storage:   s,
+		locker:    locker,
+		log:       log,
+		interval:  sc.CleanupInterval,
+		retention: sc.CleanupRetention,
+		batchSize: sc.CleanupBatchSize,
+	}
+}
+
+// Run запускает очистку по таймеру до отмены ctx
+func (r *SessionReaper) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		r.log.Info("Session cleanup is disabled.")
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.reap(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				r.log.Errorw("session cleanup failed", "error", err)
+			}
+		}
+	}
+}
+
+func (r *SessionReaper) reap(ctx context.Context) error {
+	// Блокировка живет не дольше интервала: если реплика упала, следующий проход возьмет другая
+	token, err := r.locker.TryLock(ctx, sessionReaperLockKey, r.interval)
+	if err != nil {
+		return fmt.Errorf("acquire session reaper lock: %w", err)
+	}
+	if token == "" {
+		r.log.Debug("Skipping session cleanup: another replica holds the lock.")
+		return nil
+	}
+	defer func() {
+		// ctx может быть уже отменен, а блокировку все равно нужно отпустить
+		if err := r.locker.Unlock(context.WithoutCancel(ctx), sessionReaperLockKey, token); err != nil {
+			r.log.Errorw("failed to release session reaper lock", "error", err)
+		}
+	}()
+
+	cutoff := time.Now().UTC().Add(-r.retention)
+	var total int64
+	for {
+		deleted, err := r.storage.DeleteStaleSessions(ctx, cutoff, r.batchSize)
+		if err != nil {
+			return fmt.Errorf("delete stale sessions (deleted %d so far): %w", total, err)
+		}
+		total += deleted
+		if deleted < int64(r.batchSize) {
+			break
+		}
+	}
+
+	r.log.Infow("session cleanup finished", "deleted", total, "cutoff", cutoff)
+	return nil
+}
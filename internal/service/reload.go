@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+// ConfigReloader перечитывает конфигурацию по SIGHUP и применяет то, что можно менять на лету:
+// лимиты запросов, настройки webhook'ов, уровень логирования, API-ключ и ключ подписи JWT.
+// Конфигурация с ошибками не применяется вовсе, остается прежняя.
+type ConfigReloader struct {
+	path      string
+	current   *util.Config
+	logLevel  zap.AtomicLevel
+	rateLimit *atomic.Pointer[util.RateLimiterConfig]
+	apiKeys   *APIKeyService
+	keyRing   *KeyRingService
+	webhooks  *WebhookService
+	hup       chan os.Signal
+	log       *zap.SugaredLogger
+}
+
+func NewConfigReloader(
+	path string,
+	cfg *util.Config,
+	logLevel zap.AtomicLevel,
+	rateLimit *atomic.Pointer[util.RateLimiterConfig],
+	aks *APIKeyService,
+	krs *KeyRingService,
+	ws *WebhookService,
+	log *zap.SugaredLogger,
+) *ConfigReloader {
+	// Подписка на SIGHUP сразу: без нее сигнал, пришедший до запуска Run, завершит процесс
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	return &ConfigReloader{
+		path:      path,
+		current:   cfg,
+		logLevel:  logLevel,
+		rateLimit: rateLimit,
+		apiKeys:   aks,
+		keyRing:   krs,
+		webhooks:  ws,
+		hup:       hup,
+		log:       log,
+	}
+}
+
+// Run обрабатывает SIGHUP до отмены ctx
+func (r *ConfigReloader) Run(ctx context.Context) {
+	defer signal.Stop(r.hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.hup:
+			r.log.Info("SIGHUP received, reloading config...")
+			if err := r.Reload(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				r.log.Errorw("config reload failed", "error", err)
+			}
+		}
+	}
+}
+
+// Reload загружает и проверяет конфигурацию и применяет изменившиеся секции.
+// Все проверки, включая разбор ключа подписи и каталог событий webhook'ов, выполняются до применения:
+// конфигурация с ошибкой не применяется вовсе. Если секция не применилась из-за недоступности БД или Redis,
+// остальные применяются, а она остается прежней в current и будет повторена следующим SIGHUP.
+func (r *ConfigReloader) Reload(ctx context.Context) error {
+	next, err := util.LoadConfig(r.path)
+	if err != nil {
+		// Прежняя конфигурация продолжает действовать
+		return err
+	}
+	prev := r.current
+
+	keyChanged := signingKeyChanged(&prev.Token, &next.Token)
+	if err := validateReloadable(next, keyChanged); err != nil {
+		return err
+	}
+
+	// applied - то, что действует после Reload: prev с замененными успешно примененными секциями
+	applied := *prev
+	var errs []error
+	if next.Log != prev.Log {
+		// Уровень уже проверен в LoadConfig
+		level, _ := zapcore.ParseLevel(next.Log.Level)
+		r.logLevel.SetLevel(level)
+		applied.Log = next.Log
+		r.log.Infow("log level changed", "level", level)
+	}
+	if !reflect.DeepEqual(prev.RateLimit, next.RateLimit) {
+		r.rateLimit.Store(&next.RateLimit)
+		applied.RateLimit = next.RateLimit
+		r.log.Infow("rate limit changed", "limit", next.RateLimit.Limit, "interval", next.RateLimit.Interval,
+			"policies", len(next.RateLimit.Policies), "allowlist", next.RateLimit.Allowlist)
+	}
+	if next.APIKey != prev.APIKey {
+		if err := r.apiKeys.SetAPIKey(ctx, &next.APIKey); err != nil {
+			errs = append(errs, fmt.Errorf("api_key: %w", err))
+		} else {
+			applied.APIKey = next.APIKey
+		}
+	}
+	if keyChanged {
+		if err := r.keyRing.SetConfiguredKey(ctx, &next.Token); err != nil {
+			errs = append(errs, fmt.Errorf("token signing key: %w", err))
+		} else {
+			applied.Token.SigningAlg = next.Token.SigningAlg
+			applied.Token.JWTSecret = next.Token.JWTSecret
+			applied.Token.PrivateKey = next.Token.PrivateKey
+			applied.Token.KeyID = next.Token.KeyID
+		}
+	}
+	if !reflect.DeepEqual(prev.Webhook, next.Webhook) {
+		webhook := next.Webhook
+		// Workers и Timeout применяются только при старте
+		webhook.Workers, webhook.Timeout = prev.Webhook.Workers, prev.Webhook.Timeout
+		if err := r.webhooks.SetConfig(ctx, &webhook); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		} else {
+			applied.Webhook = webhook
+		}
+	}
+
+	if sections := restartRequired(prev, next); len(sections) > 0 {
+		r.log.Warnw("config changes that require restart are ignored", "sections", sections)
+	}
+
+	r.current = &applied
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	r.log.Info("Config reloaded successfully.")
+	return nil
+}
+
+// validateReloadable проверяет то, что LoadConfig не проверяет, но без чего секцию не применить:
+// ключ подписи разбирается, типы событий webhook'ов есть в каталоге
+func validateReloadable(next *util.Config, keyChanged bool) error {
+	var errs []error
+	if keyChanged {
+		if _, _, err := loadConfiguredKey(&next.Token); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if next.Webhook.URL != "" {
+		if err := ValidateEventTypes(next.Webhook.Events); err != nil {
+			errs = append(errs, fmt.Errorf("webhook.events (WEBHOOK_EVENTS): %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func signingKeyChanged(prev, next *util.TokenConfig) bool {
+	return prev.SigningAlg != next.SigningAlg || prev.JWTSecret != next.JWTSecret ||
+		prev.PrivateKey != next.PrivateKey || prev.KeyID != next.KeyID
+}
+
+// restartRequired секции, изменения которых Reload не применяет
+func restartRequired(prev, next *util.Config) []string {
+	var sections []string
+	if !reflect.DeepEqual(prev.Server, next.Server) {
+		sections = append(sections, "server")
+	}
+	if prev.DB != next.DB {
+		sections = append(sections, "database")
+	}
+	if prev.Redis != next.Redis {
+		sections = append(sections, "redis")
+	}
+	if prev.Session != next.Session {
+		sections = append(sections, "session")
+	}
+	if prev.Tracing != next.Tracing {
+		sections = append(sections, "tracing")
+	}
+
+	prevToken, nextToken := prev.Token, next.Token
+	prevToken.SigningAlg, prevToken.JWTSecret, prevToken.PrivateKey, prevToken.KeyID = "", "", "", ""
+	nextToken.SigningAlg, nextToken.JWTSecret, nextToken.PrivateKey, nextToken.KeyID = "", "", "", ""
+	if prevToken != nextToken {
+		sections = append(sections, "token")
+	}
+	if prev.Webhook.Workers != next.Webhook.Workers || prev.Webhook.Timeout != next.Webhook.Timeout {
+		sections = append(sections, "webhook.workers/timeout")
+	}
+	return sections
+}
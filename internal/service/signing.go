@@ -0,0 +1,199 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+)
+
+const (
+	AlgHS512 = "HS512"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+
+	kidLength        = 16
+	hmacSecretLength = 64
+	ecP256KeySize    = 32
+	minRSAKeyBits    = 2048
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrInvalidSigningKey    = errors.New("invalid signing key")
+)
+
+// SigningKey ключ подписи access-токенов.
+// Для HS512 sign и verify - один и тот же секрет, для асимметричных
+// алгоритмов подписываем приватным ключом, а проверяем публичным.
+type SigningKey struct {
+	KID       string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+// NewSigningKey создает ключ подписи для алгоритма alg.
+// material - HMAC-секрет для HS512 или PEM приватного ключа для RS256/ES256/EdDSA.
+// Если kid пустой, он вычисляется из ключа (стабилен между рестартами и репликами).
+func NewSigningKey(alg string, material []byte, kid string) (*SigningKey, error) {
+	if alg == AlgHS512 {
+		if len(material) == 0 {
+			return nil, fmt.Errorf("%w: empty HMAC secret", ErrInvalidSigningKey)
+		}
+		if kid == "" {
+			kid = deriveKID(append([]byte("hmac:"), material...))
+		}
+		return &SigningKey{KID: kid, Method: jwt.SigningMethodHS512, signKey: material, verifyKey: material}, nil
+	}
+
+	privateKey, err := parsePrivateKey(material)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{KID: kid, signKey: privateKey}
+	switch alg {
+	case AlgRS256:
+		k, ok := privateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s requires an RSA private key", ErrInvalidSigningKey, alg)
+		}
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("%w: RSA key must be at least %d bits", ErrInvalidSigningKey, minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.verifyKey = &k.PublicKey
+	case AlgES256:
+		k, ok := privateKey.(*ecdsa.PrivateKey)
+		if !ok || k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("%w: %s requires a P-256 EC private key", ErrInvalidSigningKey, alg)
+		}
+		key.Method = jwt.SigningMethodES256
+		key.verifyKey = &k.PublicKey
+	case AlgEdDSA:
+		k, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s requires an Ed25519 private key", ErrInvalidSigningKey, alg)
+		}
+		key.Method = jwt.SigningMethodEdDSA
+		key.verifyKey = k.Public()
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+
+	if key.KID == "" {
+		der, err := x509.MarshalPKIXPublicKey(key.verifyKey)
+		if err != nil {
+			return nil, fmt.Errorf("marshal public key: %w", err)
+		}
+		key.KID = deriveKID(der)
+	}
+
+	return key, nil
+}
+
+// JWK возвращает публичную часть ключа. Для симметричных ключей ok = false.
+func (k *SigningKey) JWK() (jwk models.JWK, ok bool) {
+	jwk = models.JWK{Kid: k.KID, Use: "sig", Alg: k.Method.Alg()}
+
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return models.JWK{}, false
+		}
+		// uncompressed point: 0x04 || X || Y
+		point := ecdhKey.Bytes()
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+ecP256KeySize])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+ecP256KeySize:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return models.JWK{}, false
+	}
+
+	return jwk, true
+}
+
+// GenerateKeyMaterial генерирует новый ключ для alg в формате, который принимает NewSigningKey
+func GenerateKeyMaterial(alg string) ([]byte, error) {
+	var (
+		privateKey any
+		err        error
+	)
+	switch alg {
+	case AlgHS512:
+		secret := make([]byte, hmacSecretLength)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		return secret, nil
+	case AlgRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	case AlgES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generate %s key: %w", alg, err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func parsePrivateKey(privateKeyPEM []byte) (any, error) {
+	if len(privateKeyPEM) == 0 {
+		return nil, fmt.Errorf("%w: private key is not set", ErrInvalidSigningKey)
+	}
+
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("%w: failed to decode PEM block", ErrInvalidSigningKey)
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: unsupported private key format %q", ErrInvalidSigningKey, block.Type)
+}
+
+func deriveKID(material []byte) string {
+	sum := sha256.Sum256(material)
+	return base64.RawURLEncoding.EncodeToString(sum[:])[:kidLength]
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func mustGenerate(t *testing.T, alg string) []byte {
+	t.Helper()
+	material, err := GenerateKeyMaterial(alg)
+	if err != nil {
+		t.Fatalf("GenerateKeyMaterial(%s) error = %v", alg, err)
+	}
+	return material
+}
+
+func pemBlock(t *testing.T, blockType string, der []byte) []byte {
+	t.Helper()
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func TestNewSigningKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384DER, err := x509.MarshalPKCS8PrivateKey(p384Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	es256 := mustGenerate(t, AlgES256)
+	tests := []struct {
+		name     string
+		alg      string
+		material []byte
+		kid      string
+		wantAlg  string
+		wantErr  error
+	}{
+		{name: "hs512", alg: AlgHS512, material: []byte("secret"), wantAlg: "HS512"},
+		{name: "rs256", alg: AlgRS256, material: mustGenerate(t, AlgRS256), wantAlg: "RS256"},
+		{name: "es256", alg: AlgES256, material: es256, wantAlg: "ES256"},
+		{name: "es256 sec1 pem", alg: AlgES256, material: pemBlock(t, "EC PRIVATE KEY", ecDER), wantAlg: "ES256"},
+		{name: "eddsa", alg: AlgEdDSA, material: mustGenerate(t, AlgEdDSA), wantAlg: "EdDSA"},
+		{name: "explicit kid", alg: AlgES256, material: es256, kid: "key-1", wantAlg: "ES256"},
+		{name: "empty hmac secret", alg: AlgHS512, wantErr: ErrInvalidSigningKey},
+		{name: "empty private key", alg: AlgES256, wantErr: ErrInvalidSigningKey},
+		{name: "not pem", alg: AlgES256, material: []byte("secret"), wantErr: ErrInvalidSigningKey},
+		{
+			name:     "unknown pem",
+			alg:      AlgES256,
+			material: pemBlock(t, "CERTIFICATE", []byte{1, 2}),
+			wantErr:  ErrInvalidSigningKey,
+		},
+		{name: "key of another type", alg: AlgRS256, material: es256, wantErr: ErrInvalidSigningKey},
+		{
+			name:     "p-384 for es256",
+			alg:      AlgES256,
+			material: pemBlock(t, "PRIVATE KEY", p384DER),
+			wantErr:  ErrInvalidSigningKey,
+		},
+		{
+			name:     "short rsa key",
+			alg:      AlgRS256,
+			material: pemBlock(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(smallRSAKey)),
+			wantErr:  ErrInvalidSigningKey,
+		},
+		{name: "unsupported algorithm", alg: "PS256", material: es256, wantErr: ErrUnsupportedAlgorithm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewSigningKey(tt.alg, tt.material, tt.kid)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewSigningKey() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSigningKey() error = %v", err)
+			}
+			if key.Method.Alg() != tt.wantAlg {
+				t.Errorf("Method = %s, want %s", key.Method.Alg(), tt.wantAlg)
+			}
+			if tt.kid != "" && key.KID != tt.kid {
+				t.Errorf("KID = %q, want %q", key.KID, tt.kid)
+			}
+			if tt.kid == "" && len(key.KID) != kidLength {
+				t.Errorf("derived KID = %q, want %d characters", key.KID, kidLength)
+			}
+
+			// Подписанный ключом токен проверяется им же
+			signed, err := jwt.NewWithClaims(key.Method, jwt.MapClaims{"sub": "1"}).SignedString(key.signKey)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			if _, err := jwt.Parse(signed, func(*jwt.Token) (any, error) { return key.verifyKey, nil }); err != nil {
+				t.Errorf("verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewSigningKeyDerivedKIDIsStable(t *testing.T) {
+	for _, alg := range []string{AlgHS512, AlgRS256, AlgES256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			material := mustGenerate(t, alg)
+			first, err := NewSigningKey(alg, material, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := NewSigningKey(alg, material, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first.KID != second.KID {
+				t.Errorf("KID changed between loads: %q != %q", first.KID, second.KID)
+			}
+
+			other, err := NewSigningKey(alg, mustGenerate(t, alg), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if other.KID == first.KID {
+				t.Errorf("different keys share KID %q", first.KID)
+			}
+		})
+	}
+}
+
+func TestSigningKeyJWK(t *testing.T) {
+	decode := func(t *testing.T, s string) []byte {
+		t.Helper()
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("field %q is not base64url: %v", s, err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		alg     string
+		wantOK  bool
+		wantKty string
+		wantCrv string
+		check   func(t *testing.T, key *SigningKey, x, y, n, e string)
+	}{
+		{alg: AlgHS512},
+		{
+			alg:     AlgRS256,
+			wantOK:  true,
+			wantKty: "RSA",
+			check: func(t *testing.T, key *SigningKey, _, _, n, e string) {
+				pub := key.verifyKey.(*rsa.PublicKey)
+				if new(big.Int).SetBytes(decode(t, n)).Cmp(pub.N) != 0 {
+					t.Error("n does not match the public key")
+				}
+				if int(new(big.Int).SetBytes(decode(t, e)).Int64()) != pub.E {
+					t.Error("e does not match the public key")
+				}
+			},
+		},
+		{
+			alg:     AlgES256,
+			wantOK:  true,
+			wantKty: "EC",
+			wantCrv: "P-256",
+			check: func(t *testing.T, key *SigningKey, x, y, _, _ string) {
+				pub := key.verifyKey.(*ecdsa.PublicKey)
+				if len(decode(t, x)) != ecP256KeySize || len(decode(t, y)) != ecP256KeySize {
+					t.Fatal("x and y must be 32 bytes")
+				}
+				if new(big.Int).SetBytes(decode(t, x)).Cmp(pub.X) != 0 ||
+					new(big.Int).SetBytes(decode(t, y)).Cmp(pub.Y) != 0 {
+					t.Error("x, y do not match the public key")
+				}
+			},
+		},
+		{
+			alg:     AlgEdDSA,
+			wantOK:  true,
+			wantKty: "OKP",
+			wantCrv: "Ed25519",
+			check: func(t *testing.T, _ *SigningKey, x, _, _, _ string) {
+				if len(decode(t, x)) != 32 {
+					t.Error("x must be 32 bytes")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			key, err := NewSigningKey(tt.alg, mustGenerate(t, tt.alg), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			jwk, ok := key.JWK()
+			if ok != tt.wantOK {
+				t.Fatalf("JWK() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if jwk.Kid != key.KID || jwk.Use != "sig" || jwk.Alg != tt.alg {
+				t.Errorf("JWK() = %+v, want kid %q, use sig, alg %s", jwk, key.KID, tt.alg)
+			}
+			if jwk.Kty != tt.wantKty || jwk.Crv != tt.wantCrv {
+				t.Errorf("JWK() kty %q crv %q, want %q %q", jwk.Kty, jwk.Crv, tt.wantKty, tt.wantCrv)
+			}
+			tt.check(t, key, jwk.X, jwk.Y, jwk.N, jwk.E)
+		})
+	}
+}
@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -16,6 +19,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 
+	"github.com/rryowa/medods_dvortsov/internal/models"
 	"github.com/rryowa/medods_dvortsov/internal/storage"
 	"github.com/rryowa/medods_dvortsov/internal/util"
 )
@@ -27,18 +31,23 @@ var (
 	ErrTokenRevoked         = errors.New("token revoked")
 	ErrInvalidUserID        = errors.New("invalid userID")
 	ErrInvalidSigningMethod = errors.New("invalid signing method")
+	ErrUnknownKeyID         = errors.New("unknown key id")
 )
 
 type TokenService struct {
-	JwtSecretKey []byte
+	keyRing      *KeyRingService
 	accessTTL    time.Duration
 	refreshTTL   time.Duration
 	tokenStorage storage.TokenStorage
 }
 
-func NewTokenService(cfg *util.TokenConfig, tokenStorage storage.TokenStorage) *TokenService {
+func NewTokenService(
+	cfg *util.TokenConfig,
+	keyRing *KeyRingService,
+	tokenStorage storage.TokenStorage,
+) *TokenService {
 	return &TokenService{
-		JwtSecretKey: cfg.JwtSecretKey,
+		keyRing:      keyRing,
 		accessTTL:    cfg.AccessTTL,
 		refreshTTL:   cfg.RefreshTTL,
 		tokenStorage: tokenStorage,
@@ -50,7 +59,15 @@ type jwtClaims struct {
 	jwt.RegisteredClaims
 }
 
-// CreateAccessToken создает SHA512 signed access токен с новым JTI
+func (c *jwtClaims) userID() (int64, error) {
+	userID, err := strconv.ParseInt(c.UserID, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidUserID
+	}
+	return userID, nil
+}
+
+// CreateAccessToken создает подписанный access токен с новым JTI
 func (ts *TokenService) CreateAccessToken(userID int64, now time.Time) (string, string, error) {
 	jti := uuid.NewString()
 	signedToken, err := ts.CreateAccessTokenWithJTI(userID, now, jti)
@@ -60,7 +77,7 @@ func (ts *TokenService) CreateAccessToken(userID int64, now time.Time) (string,
 	return signedToken, jti, nil
 }
 
-// CreateAccessTokenWithJTI создает SHA512 signed access токен с JTI
+// CreateAccessTokenWithJTI создает access токен с JTI, подписанный текущим ключом (kid в заголовке)
 func (ts *TokenService) CreateAccessTokenWithJTI(userID int64, now time.Time, jti string) (string, error) {
 	claims := &jwtClaims{
 		UserID: strconv.FormatInt(userID, 10),
@@ -72,8 +89,14 @@ func (ts *TokenService) CreateAccessTokenWithJTI(userID int64, now time.Time, jt
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
-	signedToken, err := token.SignedString(ts.JwtSecretKey)
+	signingKey := ts.keyRing.Current()
+	if signingKey == nil {
+		return "", ErrNoActiveSigningKey
+	}
+
+	token := jwt.NewWithClaims(signingKey.Method, claims)
+	token.Header["kid"] = signingKey.KID
+	signedToken, err := token.SignedString(signingKey.signKey)
 	if err != nil {
 		return "", fmt.Errorf("signed string: %w", err)
 	}
@@ -129,8 +152,80 @@ func (ts *TokenService) ValidateAccessTokenAndGetUserID(ctx context.Context, tok
 		return 0, ErrTokenRevoked
 	}
 
+	claims, err := ts.parseAccessToken(token)
+	if err != nil {
+		return 0, err
+	}
+
+	return claims.userID()
+}
+
+// VerifyAccessToken проверяет подпись и срок жизни access-токена ключами связки, без denylist
+func (ts *TokenService) VerifyAccessToken(token string) error {
+	_, err := ts.parseAccessToken(token)
+	return err
+}
+
+// JWKS возвращает публичные ключи для офлайн-проверки токенов.
+// HS512-ключи не публикуются.
+func (ts *TokenService) JWKS() []models.JWK {
+	return ts.keyRing.JWKS()
+}
+
+func (ts *TokenService) InvalidateAccessToken(ctx context.Context, accessToken string) error {
+	claims, err := ts.getClaimsFromToken(accessToken)
+	if err != nil {
+		return fmt.Errorf("get claims from token: %w", err)
+	}
+
+	if claims.ExpiresAt == nil {
+		return ts.InvalidateAccessTokenJTI(ctx, claims.ID)
+	}
+
+	expiration := time.Until(claims.ExpiresAt.Time)
+	if expiration <= 0 {
+		// Просроченный токен и так не пройдет проверку
+		return nil
+	}
+
+	if err := ts.tokenStorage.InvalidateToken(ctx, claims.ID, expiration); err != nil {
+		return fmt.Errorf("invalidate token: %w", err)
+	}
+	return nil
+}
+
+// InvalidateAccessTokenJTI помещает JTI в denylist, когда самого токена нет на руках
+// (отзыв по refresh-токену или по сессии). Срок - максимально возможный срок жизни токена.
+func (ts *TokenService) InvalidateAccessTokenJTI(ctx context.Context, jti string) error {
+	if jti == "" {
+		return nil
+	}
+	if err := ts.tokenStorage.InvalidateToken(ctx, jti, ts.accessTTL+util.JWTLeeWay); err != nil {
+		return fmt.Errorf("invalidate token: %w", err)
+	}
+	return nil
+}
+
+// IsAccessTokenInvalidated проверяет, находится ли JTI токена в черном списке
+// Это первый шаг валидации токена, до проверки подписи и срока действия
+func (ts *TokenService) IsAccessTokenInvalidated(ctx context.Context, accessToken string) (bool, error) {
+	claims, err := ts.getClaimsFromToken(accessToken)
+	if err != nil || claims.ID == "" {
+		// Неразборчивый токен отклонит проверка подписи
+		return false, nil
+	}
+
+	isInvalidated, err := ts.tokenStorage.IsTokenInvalidated(ctx, claims.ID)
+	if err != nil {
+		return false, fmt.Errorf("is token invalidated: %w", err)
+	}
+	return isInvalidated, nil
+}
+
+// parseAccessToken проверяет подпись и срок жизни access-токена (без denylist)
+func (ts *TokenService) parseAccessToken(token string) (*jwtClaims, error) {
 	opts := []jwt.ParserOption{
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS512.Alg()}),
+		jwt.WithValidMethods(ts.keyRing.Algorithms()),
 		jwt.WithLeeway(util.JWTLeeWay),
 		jwt.WithExpirationRequired(),
 	}
@@ -138,57 +233,114 @@ func (ts *TokenService) ValidateAccessTokenAndGetUserID(ctx context.Context, tok
 	parsedToken, err := jwt.ParseWithClaims(
 		token,
 		&jwtClaims{},
-		func(t *jwt.Token) (interface{}, error) {
-			if t.Method.Alg() != jwt.SigningMethodHS512.Alg() {
-				return nil, ErrInvalidSigningMethod
-			}
-			return ts.JwtSecretKey, nil
-		},
+		ts.verificationKey,
 		opts...,
 	)
 	if err != nil {
-		return 0, fmt.Errorf("parse token claims: %w", err)
+		return nil, fmt.Errorf("parse token claims: %w", err)
 	}
 
 	if parsedToken == nil || !parsedToken.Valid {
-		return 0, ErrTokenInvalid
+		return nil, ErrTokenInvalid
 	}
 
 	claims, ok := parsedToken.Claims.(*jwtClaims)
 	if !ok || claims.UserID == "" {
-		return 0, ErrTokenInvalid
+		return nil, ErrTokenInvalid
 	}
 
-	userID, err := strconv.ParseInt(claims.UserID, 10, 64)
+	return claims, nil
+}
+
+// rotationResult пара, выданная при ротации refresh-токена.
+// Хранится на время grace window, чтобы ретрай клиента получил ту же пару.
+type rotationResult struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// OldAccessTokenJTI и UserAgent - то, что было у клиента при ротации
+	OldAccessTokenJTI string `json:"old_jti"`
+	UserAgent         string `json:"user_agent"`
+}
+
+// sealRotationResult шифрует результат ротации ключом из verifier старого refresh-токена:
+// расшифровать его может только владелец старого токена, даже при доступе к Redis.
+func (ts *TokenService) sealRotationResult(oldRefreshToken string, result rotationResult) ([]byte, error) {
+	gcm, err := rotationCipher(oldRefreshToken)
 	if err != nil {
-		return 0, ErrInvalidUserID
+		return nil, err
 	}
 
-	return userID, nil
+	plaintext, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("marshal rotation result: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
 }
 
-func (ts *TokenService) InvalidateAccessToken(ctx context.Context, accessToken string) error {
-	claims, err := ts.getClaimsFromToken(accessToken)
+func (ts *TokenService) openRotationResult(oldRefreshToken string, sealed []byte) (*rotationResult, error) {
+	gcm, err := rotationCipher(oldRefreshToken)
 	if err != nil {
-		return fmt.Errorf("get claims from token: %w", err)
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrTokenInvalid
 	}
 
-	expiration := time.Until(claims.ExpiresAt.Time)
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
 
-	if err := ts.tokenStorage.InvalidateToken(ctx, accessToken, expiration); err != nil {
-		return fmt.Errorf("invalidate token: %w", err)
+	var result rotationResult
+	if err := json.Unmarshal(plaintext, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal rotation result: %w", err)
 	}
-	return nil
+	return &result, nil
 }
 
-// IsAccessTokenInvalidated проверяет, находится ли токен в черном списке
-// Это первый шаг валидации токена, до проверки подписи и срока действия
-func (ts *TokenService) IsAccessTokenInvalidated(ctx context.Context, accessToken string) (bool, error) {
-	isInvalidated, err := ts.tokenStorage.IsTokenInvalidated(ctx, accessToken)
+func rotationCipher(refreshToken string) (cipher.AEAD, error) {
+	_, verifier, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return nil, errors.New("invalid token format")
+	}
+	key := sha256.Sum256([]byte("refresh-rotation:" + verifier))
+
+	block, err := aes.NewCipher(key[:])
 	if err != nil {
-		return false, fmt.Errorf("is token invalidated: %w", err)
+		return nil, fmt.Errorf("new cipher: %w", err)
 	}
-	return isInvalidated, nil
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("new gcm: %w", err)
+	}
+	return gcm, nil
+}
+
+// verificationKey выбирает ключ проверки по kid из заголовка токена.
+// Токены без kid (выпущенные до key ring) проверяются текущим ключом.
+func (ts *TokenService) verificationKey(t *jwt.Token) (interface{}, error) {
+	var key *SigningKey
+	if kid, ok := t.Header["kid"].(string); ok {
+		k, found := ts.keyRing.Lookup(kid)
+		if !found {
+			return nil, ErrUnknownKeyID
+		}
+		key = k
+	} else {
+		key = ts.keyRing.Current()
+	}
+
+	// Алгоритм берем из ключа, а не из токена (alg confusion)
+	if key == nil || t.Method.Alg() != key.Method.Alg() {
+		return nil, ErrInvalidSigningMethod
+	}
+	return key.verifyKey, nil
 }
 
 func (ts *TokenService) getClaimsFromToken(token string) (*jwtClaims, error) {
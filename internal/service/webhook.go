@@ -4,57 +4,411 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/metrics"
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+	"github.com/rryowa/medods_dvortsov/internal/util"
 )
 
 const (
 	defaultHTTPStatusThreshold = 300
+	// maxWebhookResponseSnippet сколько байт ответа получателя сохранять в истории доставок
+	maxWebhookResponseSnippet = 512
+
+	WebhookEventIDHeader   = "X-Webhook-Event-ID"
+	WebhookEventTypeHeader = "X-Webhook-Event-Type"
+
+	traceParentHeader = "traceparent"
+
+	webhookDialTimeout = 30 * time.Second
+	webhookKeepAlive   = 30 * time.Second
 )
 
+var ErrUnknownEventType = errors.New("unknown webhook event type")
+
+//nolint:gochecknoglobals // провайдер подставляется otel при настройке трейсинга
+var webhookTracer = otel.Tracer("github.com/rryowa/medods_dvortsov/internal/service/webhook")
+
+// WebhookService доставляет события безопасности из transactional outbox.
+// События пишутся в БД вместе с изменением сессии, отдельно для каждого подписанного endpoint'а,
+// поэтому не теряются при ошибке получателя или рестарте: воркеры повторяют доставку
+// с экспоненциальной задержкой, после MaxAttempts попыток событие переходит в dead.
 type WebhookService struct {
-	client     *http.Client
-	log        *zap.SugaredLogger
-	webhookURL string
+	// client доставляет endpoint'ам из API, адрес получателя проверяется при каждом соединении.
+	// operatorClient доставляет на WEBHOOK_URL, который задает оператор, без проверки
+	client         *http.Client
+	operatorClient *http.Client
+	storage        storage.Storage
+	metrics        *metrics.Metrics
+	log            *zap.SugaredLogger
+	// cfg заменяется при перечитывании конфигурации (SIGHUP). Workers и Timeout
+	// применяются только при старте
+	cfg atomic.Pointer[util.WebhookConfig]
 }
 
-func NewWebhookService(log *zap.SugaredLogger, webhookURL string) *WebhookService {
-	return &WebhookService{
-		client:     &http.Client{},
-		log:        log,
-		webhookURL: webhookURL,
+func NewWebhookService(
+	cfg *util.WebhookConfig,
+	s storage.Storage,
+	m *metrics.Metrics,
+	log *zap.SugaredLogger,
+) *WebhookService {
+	ws := &WebhookService{
+		storage: s,
+		metrics: m,
+		log:     log,
 	}
+	ws.cfg.Store(cfg)
+	ws.client = newWebhookClient(cfg.Timeout, ws.guardedTransport())
+	ws.operatorClient = newWebhookClient(cfg.Timeout, http.DefaultTransport)
+	return ws
 }
 
-func (s *WebhookService) NotifyIPChange(ctx context.Context, data map[string]interface{}) {
-	go func() {
-		if s.webhookURL == "" {
-			return
-		}
+// newWebhookClient не выполняет редиректы: 3xx - неудачная попытка, иначе получатель мог бы
+// перенаправить доставку на внутренний адрес. Транспорт пишет клиентский спан и передает traceparent получателю
+func newWebhookClient(timeout time.Duration, transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: otelhttp.NewTransport(transport),
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
 
-		payload, err := json.Marshal(data)
-		if err != nil {
-			s.log.Errorw("failed to marshal webhook payload", "error", err)
-			return
-		}
+// guardedTransport проверяет адрес, к которому фактически подключается, уже после разрешения DNS:
+// смена DNS-записи после сохранения endpoint'а не открывает внутренние адреса.
+// Прокси из окружения не используется: проверялся бы адрес прокси, а не получателя
+func (s *WebhookService) guardedTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:        webhookDialTimeout,
+		KeepAlive:      webhookKeepAlive,
+		ControlContext: s.checkDialAddr,
+	}
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewBuffer(payload))
-		if err != nil {
-			s.log.Errorw("failed to create webhook request", "error", err)
-			return
+func (s *WebhookService) checkDialAddr(_ context.Context, _, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidWebhookURL, err)
+	}
+	if !s.isAllowedWebhookAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: disallowed address %s", ErrInvalidWebhookURL, addrPort.Addr())
+	}
+	return nil
+}
+
+// SetConfig заменяет настройки доставки и заводит endpoint, если изменился WEBHOOK_URL.
+// Endpoint прежнего URL остается в БД: им, как и остальными, управляют через API.
+func (s *WebhookService) SetConfig(ctx context.Context, cfg *util.WebhookConfig) error {
+	s.cfg.Store(cfg)
+	return s.SyncEndpoint(ctx)
+}
+
+// SyncEndpoint заводит endpoint из WEBHOOK_URL с подписками WEBHOOK_EVENTS.
+// Повторный запуск обновляет только секрет: подписки могли быть изменены через API.
+func (s *WebhookService) SyncEndpoint(ctx context.Context) error {
+	cfg := s.cfg.Load()
+	if cfg.URL == "" {
+		s.log.Info("Skipping webhook endpoint sync: WEBHOOK_URL is not set.")
+		return nil
+	}
+	if err := ValidateEventTypes(cfg.Events); err != nil {
+		return fmt.Errorf("WEBHOOK_EVENTS: %w", err)
+	}
+	if err := ValidateWebhookFormat(cfg.Format); err != nil {
+		return fmt.Errorf("WEBHOOK_FORMAT: %w", err)
+	}
+	if cfg.Secret == "" {
+		s.log.Warn("WEBHOOK_SECRET is not set: deliveries to WEBHOOK_URL will not be signed.")
+	}
+
+	created, adopted, err := s.storage.SyncWebhookEndpointTx(ctx, cfg.URL, cfg.Secret, cfg.Format, cfg.Events)
+	if err != nil {
+		return fmt.Errorf("failed to sync webhook endpoint: %w", err)
+	}
+	if created {
+		s.log.Infow("Webhook endpoint created from WEBHOOK_URL", "events", cfg.Events)
+	}
+	if adopted > 0 {
+		s.log.Infow("Pending webhook events attached to WEBHOOK_URL endpoint", "events", adopted)
+	}
+	return nil
+}
+
+// ValidateEventTypes проверяет, что все типы есть в каталоге событий
+func ValidateEventTypes(eventTypes []string) error {
+	known := models.WebhookEventTypes()
+	for _, eventType := range eventTypes {
+		if eventType != models.WebhookEventAll && !slices.Contains(known, eventType) {
+			return fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
 		}
-		req.Header.Set("Content-Type", "application/json")
+	}
+	return nil
+}
+
+// NewEvent оборачивает данные события в конверт для записи в outbox.
+// ID события (см. contentEventID) вычисляется один раз и хранится в outbox, поэтому не меняется
+// между повторами доставки.
+// Вместе с событием сохраняется traceparent из ctx: доставка продолжит трейс запроса.
+func (s *WebhookService) NewEvent(ctx context.Context, data models.EventData) (models.OutboxEvent, error) {
+	now := time.Now().UTC()
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return models.OutboxEvent{}, fmt.Errorf("failed to marshal webhook event data: %w", err)
+	}
+	envelope := models.EventEnvelope{
+		ID:         contentEventID(s.cfg.Load().EventSource, data.EventType(), data.EventVersion(), now, rawData),
+		Type:       data.EventType(),
+		Version:    data.EventVersion(),
+		OccurredAt: now,
+		Data:       data,
+	}
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return models.OutboxEvent{}, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	return models.OutboxEvent{
+		EventID:       envelope.ID,
+		EventType:     envelope.Type,
+		Payload:       payload,
+		Status:        models.OutboxStatusPending,
+		NextAttemptAt: now,
+		TraceParent:   carrier.Get(traceParentHeader),
+	}, nil
+}
+
+// Run запускает воркеры доставки и ждет их завершения после отмены ctx
+func (s *WebhookService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range s.cfg.Load().Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.worker(ctx)
+		}()
+	}
+	wg.Wait()
+}
 
-		resp, err := s.client.Do(req)
-		if err != nil {
-			s.log.Errorw("failed to send webhook", "error", err)
+func (s *WebhookService) worker(ctx context.Context) {
+	for {
+		claimed, err := s.processBatch(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			s.log.Errorw("webhook delivery failed", "error", err)
+		}
+		// Очередь пуста (или БД недоступна) - ждем, иначе сразу берем следующую пачку
+		if claimed == 0 || err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.cfg.Load().PollInterval):
+			}
+		}
+		if ctx.Err() != nil {
 			return
 		}
-		defer resp.Body.Close()
+	}
+}
+
+func (s *WebhookService) processBatch(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	batchSize := s.cfg.Load().BatchSize
+	// События доставляются последовательно, блокировка должна пережить всю пачку
+	lease := s.client.Timeout * time.Duration(batchSize+1)
+	events, err := s.storage.ClaimWebhookEvents(ctx, now, now.Add(lease), batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim webhook events: %w", err)
+	}
+
+	for _, event := range events {
+		s.handle(ctx, event)
+	}
+	return len(events), nil
+}
+
+func (s *WebhookService) handle(ctx context.Context, event models.OutboxEvent) {
+	delivery, deliveryErr := s.deliver(ctx, event)
+	// Отчитываемся о доставке, даже если сервис уже останавливается
+	dbCtx := context.WithoutCancel(ctx)
 
-		if resp.StatusCode >= defaultHTTPStatusThreshold {
-			s.log.Warnw("webhook returned non-2xx status", "status", resp.StatusCode)
+	if ctx.Err() == nil {
+		s.recordDelivery(dbCtx, delivery)
+	}
+
+	var (
+		err     error
+		outcome string
+	)
+	switch {
+	case deliveryErr == nil:
+		outcome = metrics.WebhookDelivered
+		err = s.storage.MarkWebhookDelivered(dbCtx, event.ID)
+	case ctx.Err() != nil:
+		outcome = metrics.WebhookReleased
+		err = s.storage.ReleaseWebhookEvent(dbCtx, event.ID)
+	case event.Attempts >= s.cfg.Load().MaxAttempts:
+		outcome = metrics.WebhookDead
+		s.log.Errorw("webhook event moved to dead letter",
+			"eventID", event.EventID,
+			"eventType", event.EventType,
+			"endpointID", event.EndpointID,
+			"attempts", event.Attempts,
+			"error", deliveryErr,
+		)
+		err = s.storage.MarkWebhookDead(dbCtx, event.ID, deliveryErr.Error())
+	default:
+		outcome = metrics.WebhookRetry
+		next := time.Now().UTC().Add(s.backoff(event.Attempts))
+		s.log.Warnw("webhook delivery attempt failed, will retry",
+			"eventID", event.EventID,
+			"endpointID", event.EndpointID,
+			"attempts", event.Attempts,
+			"nextAttemptAt", next,
+			"error", deliveryErr,
+		)
+		err = s.storage.RetryWebhookEvent(dbCtx, event.ID, next, deliveryErr.Error())
+	}
+	if err != nil {
+		s.log.Errorw("failed to update webhook event state", "eventID", event.EventID, "error", err)
+	}
+
+	s.metrics.WebhookDeliveries.WithLabelValues(outcome).Inc()
+	latencyOutcome := metrics.OutcomeSuccess
+	if deliveryErr != nil {
+		latencyOutcome = metrics.OutcomeFailure
+	}
+	s.metrics.WebhookLatency.WithLabelValues(latencyOutcome).Observe(delivery.Latency.Seconds())
+}
+
+// deliver выполняет одну попытку доставки и возвращает ее запись для истории.
+// Спан попытки - дочерний к запросу, в котором возникло событие (traceparent из outbox).
+func (s *WebhookService) deliver(ctx context.Context, event models.OutboxEvent) (models.WebhookDelivery, error) {
+	if event.TraceParent != "" {
+		ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceParentHeader: event.TraceParent})
+	}
+	ctx, span := webhookTracer.Start(ctx, "webhook.deliver", trace.WithAttributes(
+		attribute.String("webhook.event_id", event.EventID),
+		attribute.String("webhook.event_type", event.EventType),
+		attribute.Int64("webhook.endpoint_id", event.EndpointID),
+		attribute.Int("webhook.attempt", event.Attempts),
+	))
+	defer span.End()
+
+	delivery := models.WebhookDelivery{
+		EndpointID: event.EndpointID,
+		EventID:    event.EventID,
+		EventType:  event.EventType,
+		Attempt:    event.Attempts,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if event.ID != 0 {
+		delivery.OutboxID = &event.ID
+	}
+
+	err := s.send(ctx, event, &delivery)
+	delivery.Latency = time.Since(delivery.CreatedAt)
+	if err != nil {
+		delivery.Error = err.Error()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return delivery, err
+	}
+	delivery.Success = true
+	return delivery, nil
+}
+
+func (s *WebhookService) send(ctx context.Context, event models.OutboxEvent, delivery *models.WebhookDelivery) error {
+	body, headers, err := s.encodeWebhookBody(event.EndpointFormat, event.Payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, event.EndpointURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header = headers
+	req.Header.Set(WebhookEventIDHeader, event.EventID)
+	req.Header.Set(WebhookEventTypeHeader, event.EventType)
+	if event.EndpointSecret != "" {
+		// Подписываем каждую попытку заново: у повтора свежая метка времени.
+		// Подпись считается по фактически отправленному телу.
+		req.Header.Set(WebhookSignatureHeader, signWebhookPayload([]byte(event.EndpointSecret), time.Now(), req.Header, body))
+	}
+
+	client := s.client
+	if event.EndpointURL == s.cfg.Load().URL {
+		client = s.operatorClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseSnippet))
+	// В TEXT нельзя записать невалидный UTF-8 и нулевые байты
+	snippet := strings.ToValidUTF8(strings.ReplaceAll(string(respBody), "\x00", ""), "\uFFFD")
+	delivery.StatusCode = &resp.StatusCode
+	delivery.ResponseSnippet = snippet
+
+	if resp.StatusCode >= defaultHTTPStatusThreshold {
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, snippet)
+	}
+	return nil
+}
+
+func (s *WebhookService) recordDelivery(ctx context.Context, delivery models.WebhookDelivery) {
+	if err := s.storage.CreateWebhookDelivery(ctx, delivery); err != nil {
+		s.log.Errorw("failed to record webhook delivery", "eventID", delivery.EventID, "error", err)
+	}
+}
+
+// backoff экспоненциальная задержка перед попыткой attempts+1 с джиттером:
+// половина задержки фиксирована, половина случайна, чтобы повторы не шли синхронно
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	cfg := s.cfg.Load()
+	delay := cfg.BackoffMax
+	if shift := attempts - 1; shift < 32 {
+		if d := cfg.BackoffBase << shift; d > 0 && d < delay {
+			delay = d
 		}
-	}()
+	}
+	half := delay / 2
+	//nolint:gosec // джиттер не требует криптостойкого генератора
+	return half + rand.N(half+1)
 }
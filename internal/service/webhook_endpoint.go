@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookSecretLength = 32
+)
+
+var ErrInvalidWebhookURL = errors.New("invalid webhook url")
+
+// CreateEndpoint добавляет получателя. Пустой секрет генерируется;
+// в ответе секрет заполнен, чтобы его можно было показать один раз.
+func (s *WebhookService) CreateEndpoint(
+	ctx context.Context,
+	endpoint models.WebhookEndpoint,
+) (*models.WebhookEndpoint, error) {
+	if err := s.validateWebhookURL(ctx, endpoint.URL); err != nil {
+		return nil, err
+	}
+	if err := ValidateEventTypes(endpoint.Events); err != nil {
+		return nil, err
+	}
+	if endpoint.Format == "" {
+		endpoint.Format = models.WebhookFormatJSON
+	}
+	if err := ValidateWebhookFormat(endpoint.Format); err != nil {
+		return nil, err
+	}
+	if endpoint.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		endpoint.Secret = secret
+	}
+
+	created, err := s.storage.CreateWebhookEndpointTx(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("create webhook endpoint: %w", err)
+	}
+	s.log.Infow("webhook endpoint created", "endpointID", created.ID, "events", created.Events)
+	return created, nil
+}
+
+func (s *WebhookService) GetEndpoint(ctx context.Context, id int64) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.storage.GetWebhookEndpoint(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	endpoints, err := s.storage.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// UpdateEndpoint меняет переданные поля. rotateSecret генерирует новый секрет:
+// он вернется в ответе, старый перестает действовать сразу.
+func (s *WebhookService) UpdateEndpoint(
+	ctx context.Context,
+	id int64,
+	update models.WebhookEndpointUpdate,
+	rotateSecret bool,
+) (*models.WebhookEndpoint, error) {
+	if update.URL != nil {
+		if err := s.validateWebhookURL(ctx, *update.URL); err != nil {
+			return nil, err
+		}
+	}
+	if update.Events != nil {
+		if err := ValidateEventTypes(update.Events); err != nil {
+			return nil, err
+		}
+	}
+	if update.Format != nil {
+		if err := ValidateWebhookFormat(*update.Format); err != nil {
+			return nil, err
+		}
+	}
+	if rotateSecret {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		update.Secret = &secret
+	}
+
+	updated, err := s.storage.UpdateWebhookEndpointTx(ctx, id, update)
+	if err != nil {
+		return nil, fmt.Errorf("update webhook endpoint: %w", err)
+	}
+	s.log.Infow("webhook endpoint updated", "endpointID", id, "secretRotated", rotateSecret)
+	return updated, nil
+}
+
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, id int64) error {
+	if err := s.storage.DeleteWebhookEndpoint(ctx, id); err != nil {
+		return fmt.Errorf("delete webhook endpoint: %w", err)
+	}
+	s.log.Infow("webhook endpoint deleted", "endpointID", id)
+	return nil
+}
+
+// SendTestEvent синхронно доставляет webhook.test без повторов и outbox.
+// Ошибка доставки - не ошибка метода: она в результате попытки.
+func (s *WebhookService) SendTestEvent(ctx context.Context, id int64) (models.WebhookDelivery, error) {
+	endpoint, err := s.storage.GetWebhookEndpoint(ctx, id)
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("get webhook endpoint: %w", err)
+	}
+
+	event, err := s.NewEvent(ctx, models.WebhookTestV1{EndpointID: endpoint.ID})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	event.Attempts = 1
+	event.EndpointID = endpoint.ID
+	event.EndpointURL = endpoint.URL
+	event.EndpointSecret = endpoint.Secret
+	event.EndpointFormat = endpoint.Format
+
+	delivery, _ := s.deliver(ctx, event)
+	if err := s.storage.CreateWebhookDelivery(ctx, delivery); err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("record test delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, id int64, limit int) ([]models.WebhookDelivery, error) {
+	// 404 для несуществующего endpoint'а, а не пустая история
+	if _, err := s.storage.GetWebhookEndpoint(ctx, id); err != nil {
+		return nil, fmt.Errorf("get webhook endpoint: %w", err)
+	}
+	deliveries, err := s.storage.ListWebhookDeliveries(ctx, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// validateWebhookURL проверяет URL получателя, заданного через API, чтобы сразу отклонить
+// недопустимый адрес. Доставки запрещают адреса самого хоста (loopback, link-local с metadata-сервисами
+// облаков, unspecified), multicast и частные сети, кроме WEBHOOK_ALLOWED_NETWORKS: запрос к получателю
+// можно вызвать синхронно (SendTestEvent), а начало ответа попадает в историю доставок.
+// Это же проверяется при каждом соединении (guardedTransport), поэтому смена DNS-записи после сохранения
+// не помогает. Endpoint из WEBHOOK_URL задает оператор, он не проверяется.
+func (s *WebhookService) validateWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q must be an absolute http(s) url", ErrInvalidWebhookURL, raw)
+	}
+
+	host := u.Hostname()
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		resolved, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return fmt.Errorf("%w: cannot resolve %q: %w", ErrInvalidWebhookURL, host, err)
+		}
+		addrs = resolved
+	}
+	for _, addr := range addrs {
+		if !s.isAllowedWebhookAddr(addr) {
+			return fmt.Errorf("%w: %q resolves to disallowed address %s", ErrInvalidWebhookURL, raw, addr)
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) isAllowedWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	// Ошибка разбора невозможна после Config.Validate; без списка действуют только запреты
+	allowed, _ := s.cfg.Load().AllowedNetworkPrefixes()
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return !addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
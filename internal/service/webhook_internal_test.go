@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+func newTestWebhookService(allowedNetworks ...string) *WebhookService {
+	cfg := util.DefaultConfig().Webhook
+	cfg.AllowedNetworks = allowedNetworks
+	return NewWebhookService(&cfg, nil, nil, zap.NewNop().Sugar())
+}
+
+func testOutboxEvent(url string) models.OutboxEvent {
+	return models.OutboxEvent{
+		EventID:        "0e9c1d3f-7c1a-4c5e-9a0b-2f6d8e4b1a37",
+		EventType:      models.EventWebhookTest,
+		Payload:        []byte(`{}`),
+		Attempts:       1,
+		EndpointID:     1,
+		EndpointURL:    url,
+		EndpointFormat: models.WebhookFormatJSON,
+	}
+}
+
+func TestWebhookDeliveryRefusesLoopback(t *testing.T) {
+	var hits atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer target.Close()
+
+	delivery, err := newTestWebhookService().deliver(context.Background(), testOutboxEvent(target.URL))
+	if !errors.Is(err, ErrInvalidWebhookURL) {
+		t.Fatalf("deliver() error = %v, want %v", err, ErrInvalidWebhookURL)
+	}
+	if hits.Load() != 0 || delivery.ResponseSnippet != "" {
+		t.Errorf("loopback receiver was reached: hits = %d, snippet = %q", hits.Load(), delivery.ResponseSnippet)
+	}
+}
+
+func TestWebhookDeliveryDoesNotFollowRedirects(t *testing.T) {
+	var hits atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	// Сам получатель разрешен, редирект с него на loopback выполняться не должен
+	redirectAddr := netip.MustParseAddrPort(strings.TrimPrefix(redirect.URL, "http://")).Addr()
+	s := newTestWebhookService(redirectAddr.String())
+
+	delivery, err := s.deliver(context.Background(), testOutboxEvent(redirect.URL))
+	if err == nil {
+		t.Fatal("deliver() error = nil, want redirect to be a failed attempt")
+	}
+	if delivery.StatusCode == nil || *delivery.StatusCode != http.StatusFound {
+		t.Errorf("deliver() status = %v, want %d", delivery.StatusCode, http.StatusFound)
+	}
+	if hits.Load() != 0 || strings.Contains(delivery.ResponseSnippet, "secret") {
+		t.Errorf("redirect was followed: hits = %d, snippet = %q", hits.Load(), delivery.ResponseSnippet)
+	}
+}
+
+func TestIsAllowedWebhookAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		allowed []string
+		want    bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1::1", want: true},
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "169.254.169.254"},
+		{addr: "fe80::1"},
+		{addr: "10.1.2.3"},
+		{addr: "192.168.0.1"},
+		{addr: "fd00::1"},
+		{addr: "0.0.0.0"},
+		{addr: "224.0.0.1"},
+		{addr: "10.1.2.3", allowed: []string{"10.0.0.0/8"}, want: true},
+		{addr: "10.1.2.3", allowed: []string{"192.168.0.0/16"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			s := newTestWebhookService(tt.allowed...)
+			if got := s.isAllowedWebhookAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("isAllowedWebhookAddr(%s) with %v = %v, want %v", tt.addr, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader подпись доставки в формате Stripe: "t=<unix>,v1=<hex>"
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// signedCEHeaders атрибуты binary-режима, входящие в подпись, в порядке подписи
+//
+//nolint:gochecknoglobals // неизменяемый список
+var signedCEHeaders = []string{
+	ceHeaderSpecVersion,
+	ceHeaderID,
+	ceHeaderSource,
+	ceHeaderType,
+	ceHeaderTime,
+	ceHeaderSubject,
+	ceHeaderSchemaVersion,
+}
+
+// signedAttributes канонизирует ce-* заголовки как "<name>:<value>\n" для каждого атрибута.
+// В json и structured-режиме атрибуты в теле и уже подписаны, строка пустая.
+func signedAttributes(headers http.Header) string {
+	if headers.Get(ceHeaderSpecVersion) == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, name := range signedCEHeaders {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(headers.Get(name))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// signWebhookPayload подписывает "<timestamp>.<ce-атрибуты><body>" HMAC-SHA256.
+// Метка времени входит в подпись, поэтому перехваченный запрос нельзя переотправить позже,
+// чем получатель допускает расхождение времени. В binary-режиме подписываются и ce-* заголовки:
+// иначе повтор с новым ce-id прошел бы дедупликацию, а с другим ce-type - сменил бы смысл события.
+func signWebhookPayload(secret []byte, ts time.Time, headers http.Header, body []byte) string {
+	unix := strconv.FormatInt(ts.Unix(), 10)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write([]byte(signedAttributes(headers)))
+	mac.Write(body)
+
+	return fmt.Sprintf("t=%s,v1=%s", unix, hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+const (
+	apiKeyColumns = `id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at`
+)
+
+type APIKeyRepository struct {
+	db storage.DBTX
+}
+
+func NewAPIKeyRepository(db storage.DBTX) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key models.APIKey) (*models.APIKey, error) {
+	query := `INSERT INTO api_keys (name, prefix, key_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + apiKeyColumns
+	created, err := scanAPIKey(r.db.QueryRowContext(
+		ctx,
+		query,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		pq.Array(key.Scopes),
+		key.CreatedAt,
+		key.ExpiresAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	return created, nil
+}
+
+func (r *APIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, prefix))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepository) GetAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ. Уже отозванный ключ - ErrAPIKeyNotFound, отзыв не переписывается
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id int64, now time.Time) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, now)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// ExpireAPIKey сокращает срок действия ключа до expiresAt (более ранний срок не продлевается).
+// Отозванный или уже истекший к now ключ - ErrAPIKeyNotFound
+func (r *APIKeyRepository) ExpireAPIKey(ctx context.Context, id int64, expiresAt, now time.Time) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET expires_at = LEAST(COALESCE(expires_at, $2), $2)
+		WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $3)`,
+		id, expiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to expire api key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey обновляет last_used_at, если с прошлого обновления прошло не меньше models.APIKeyTouchInterval.
+// Условие повторяет проверку сервиса: реплики, прочитавшие ключ одновременно, не пишут его каждая
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id int64, now time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at <= $3)`,
+		id, now, now.Add(-models.APIKeyTouchInterval))
+	if err != nil {
+		return fmt.Errorf("failed to touch api key: %w", err)
+	}
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		pq.Array(&key.Scopes),
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
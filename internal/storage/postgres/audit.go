@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+// auditChainLockID ключ advisory lock, под которым записи связываются в цепочку (LinkAuditEventsTx).
+// Каждая запись ссылается на хэш предыдущей, поэтому связывает один процесс на все реплики.
+// Транзакции выдачи, ротации и отзыва эту блокировку не берут: они вставляют записи без хэша
+// и не выстраиваются в общую очередь, журнал отстает от них на время до следующего связывания.
+const auditChainLockID = 7002
+
+// auditColumns для выборки с join users u
+const auditColumns = `a.id, a.occurred_at, a.event_type, a.actor, a.user_id, a.session_id, a.client_ip, a.user_agent,
+	a.outcome, a.reason, a.prev_hash, a.hash, a.chain_seq`
+
+type AuditRepository struct {
+	db storage.DBTX
+}
+
+func NewAuditRepository(db storage.DBTX) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// appendAuditEvent вставляет запись без хэша, в цепочку ее связывает LinkAuditEventsTx
+func (r *AuditRepository) appendAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	query := `INSERT INTO audit_events (occurred_at, event_type, actor, user_id, session_id, client_ip, user_agent,
+		outcome, reason) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err := r.db.ExecContext(ctx, query,
+		event.OccurredAt,
+		event.EventType,
+		event.Actor,
+		event.UserID,
+		event.SessionID,
+		event.IPAddress,
+		event.UserAgent,
+		event.Outcome,
+		event.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit event: %w", err)
+	}
+	return nil
+}
+
+// listUnlinkedAuditEvents до limit несвязанных записей в порядке id. Видны только закоммиченные:
+// запись, чья транзакция закоммитится позже, встанет в цепочку после уже связанных
+func (r *AuditRepository) listUnlinkedAuditEvents(ctx context.Context, limit int) ([]models.AuditEvent, error) {
+	query := `SELECT ` + auditColumns + `, ''
+		FROM audit_events a WHERE a.hash IS NULL ORDER BY a.id LIMIT $1`
+	return r.queryAuditEvents(ctx, query, limit)
+}
+
+func (r *AuditRepository) linkAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	query := `UPDATE audit_events SET prev_hash = $2, hash = $3, chain_seq = $4 WHERE id = $1 AND hash IS NULL`
+	res, err := r.db.ExecContext(ctx, query, event.ID, event.PrevHash, event.Hash, event.ChainSeq)
+	if err != nil {
+		return fmt.Errorf("failed to link audit event: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("audit event %d is already linked", event.ID)
+	}
+	return nil
+}
+
+func (r *AuditRepository) setAuditChainHead(ctx context.Context, head models.AuditChainHead) error {
+	query := `INSERT INTO audit_chain_head (singleton, chain_seq, event_id, hash) VALUES (TRUE, $1, $2, $3)
+		ON CONFLICT (singleton) DO UPDATE
+		SET chain_seq = EXCLUDED.chain_seq, event_id = EXCLUDED.event_id, hash = EXCLUDED.hash`
+	if _, err := r.db.ExecContext(ctx, query, head.ChainSeq, head.EventID, head.Hash); err != nil {
+		return fmt.Errorf("failed to update audit chain head: %w", err)
+	}
+	return nil
+}
+
+// GetAuditChainHead возвращает последнюю связанную запись; ChainSeq 0 - цепочка пуста
+func (r *AuditRepository) GetAuditChainHead(ctx context.Context) (models.AuditChainHead, error) {
+	var head models.AuditChainHead
+	err := r.db.QueryRowContext(ctx, `SELECT chain_seq, event_id, hash FROM audit_chain_head`).
+		Scan(&head.ChainSeq, &head.EventID, &head.Hash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return head, fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	return head, nil
+}
+
+// ListAuditChain до limit связанных записей после afterSeq в порядке цепочки
+func (r *AuditRepository) ListAuditChain(ctx context.Context, afterSeq int64, limit int) ([]models.AuditEvent, error) {
+	query := `SELECT ` + auditColumns + `, COALESCE(u.guid::text, '')
+		FROM audit_events a LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.chain_seq > $1 ORDER BY a.chain_seq LIMIT $2`
+	return r.queryAuditEvents(ctx, query, afterSeq, limit)
+}
+
+// ListAuditEvents возвращает до limit записей журнала по фильтру (см. models.AuditFilter).
+// GUID пользователя подтягивается из users: в audit_events хранится только user_id.
+func (r *AuditRepository) ListAuditEvents(
+	ctx context.Context,
+	filter models.AuditFilter,
+	limit int,
+) ([]models.AuditEvent, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.UserGUID != "" {
+		where("u.guid = $%d", filter.UserGUID)
+	}
+	if filter.EventType != "" {
+		where("a.event_type = $%d", filter.EventType)
+	}
+	if filter.IPAddress != "" {
+		where("a.client_ip = $%d", filter.IPAddress)
+	}
+	if filter.From != nil {
+		where("a.occurred_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("a.occurred_at < $%d", *filter.To)
+	}
+	if filter.AfterID != 0 {
+		where("a.id > $%d", filter.AfterID)
+	}
+	if filter.BeforeID != 0 {
+		where("a.id < $%d", filter.BeforeID)
+	}
+
+	query := `SELECT ` + auditColumns + `, COALESCE(u.guid::text, '')
+		FROM audit_events a LEFT JOIN users u ON u.id = a.user_id`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	if filter.Ascending {
+		query += ` ORDER BY a.id`
+	} else {
+		query += ` ORDER BY a.id DESC`
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(` LIMIT $%d`, len(args))
+
+	return r.queryAuditEvents(ctx, query, args...)
+}
+
+func (r *AuditRepository) queryAuditEvents(
+	ctx context.Context,
+	query string,
+	args ...any,
+) ([]models.AuditEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate audit events: %w", err)
+	}
+	return events, nil
+}
+
+func scanAuditEvent(row rowScanner) (*models.AuditEvent, error) {
+	var (
+		event     models.AuditEvent
+		userID    sql.NullInt64
+		sessionID sql.NullInt64
+		chainSeq  sql.NullInt64
+	)
+	err := row.Scan(
+		&event.ID,
+		&event.OccurredAt,
+		&event.EventType,
+		&event.Actor,
+		&userID,
+		&sessionID,
+		&event.IPAddress,
+		&event.UserAgent,
+		&event.Outcome,
+		&event.Reason,
+		&event.PrevHash,
+		&event.Hash,
+		&chainSeq,
+		&event.UserGUID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		event.UserID = &userID.Int64
+	}
+	if sessionID.Valid {
+		event.SessionID = &sessionID.Int64
+	}
+	event.ChainSeq = chainSeq.Int64
+	event.OccurredAt = event.OccurredAt.UTC()
+	return &event, nil
+}
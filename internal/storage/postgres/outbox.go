@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+// outboxColumns вместе с получателем: выборка идет с join webhook_endpoints e
+const outboxColumns = `o.id, o.event_id, o.event_type, o.payload, o.status, o.attempts, o.next_attempt_at, o.locked_until,
+	o.last_error, o.created_at, o.delivered_at, o.traceparent, o.endpoint_id, e.url, e.secret, e.format`
+
+type OutboxRepository struct {
+	db storage.DBTX
+}
+
+func NewOutboxRepository(db storage.DBTX) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// EnqueueWebhookEvent размножает событие по включенным endpoint'ам, подписанным на его тип.
+// Без подписчиков ничего не пишется.
+func (r *OutboxRepository) EnqueueWebhookEvent(ctx context.Context, event models.OutboxEvent) error {
+	query := `INSERT INTO webhook_outbox (event_id, endpoint_id, event_type, payload, next_attempt_at, traceparent)
+		SELECT $1, e.id, $2, $3, $4, $5 FROM webhook_endpoints e
+		WHERE e.enabled AND EXISTS (
+			SELECT 1 FROM webhook_subscriptions s
+			WHERE s.endpoint_id = e.id AND s.event_type IN ($2, '*')
+		)`
+	_, err := r.db.ExecContext(ctx, query,
+		event.EventID,
+		event.EventType,
+		[]byte(event.Payload),
+		event.NextAttemptAt,
+		event.TraceParent,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook event: %w", err)
+	}
+	return nil
+}
+
+// adoptOrphanedWebhookEvents привязывает к endpoint'у недоставленные события без получателя.
+// Такие события остаются после миграции с единственного WEBHOOK_URL на таблицу endpoint'ов.
+func (r *OutboxRepository) adoptOrphanedWebhookEvents(ctx context.Context, endpointID int64) (int64, error) {
+	query := `UPDATE webhook_outbox SET endpoint_id = $1 WHERE endpoint_id IS NULL AND status = 'pending'`
+	res, err := r.db.ExecContext(ctx, query, endpointID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to adopt orphaned webhook events: %w", err)
+	}
+	adopted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return adopted, nil
+}
+
+// ClaimWebhookEvents забирает до limit событий, которые пора доставлять, и блокирует их до lockedUntil.
+// Сюда же попадают события, воркер которых упал, не успев отчитаться (истек locked_until).
+// SKIP LOCKED позволяет воркерам разных реплик разбирать очередь без конфликтов.
+func (r *OutboxRepository) ClaimWebhookEvents(
+	ctx context.Context,
+	now, lockedUntil time.Time,
+	limit int,
+) ([]models.OutboxEvent, error) {
+	// События выключенного endpoint'а ждут в очереди, пока его не включат
+	query := `UPDATE webhook_outbox o SET status = 'delivering', attempts = o.attempts + 1, locked_until = $2
+		FROM webhook_endpoints e
+		WHERE e.id = o.endpoint_id AND o.id IN (
+			SELECT due.id FROM webhook_outbox due
+			JOIN webhook_endpoints de ON de.id = due.endpoint_id
+			WHERE de.enabled AND (
+				(due.status = 'pending' AND due.next_attempt_at <= $1)
+				OR (due.status = 'delivering' AND due.locked_until <= $1)
+			)
+			ORDER BY due.next_attempt_at
+			LIMIT $3
+			FOR UPDATE OF due SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns
+	rows, err := r.db.QueryContext(ctx, query, now, lockedUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		event, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook event: %w", err)
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return events, nil
+}
+
+func (r *OutboxRepository) MarkWebhookDelivered(ctx context.Context, id int64) error {
+	query := `UPDATE webhook_outbox SET status = 'delivered', delivered_at = NOW(), locked_until = NULL, last_error = NULL
+		WHERE id = $1 AND status = 'delivering'`
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark webhook event as delivered: %w", err)
+	}
+	return nil
+}
+
+// RetryWebhookEvent возвращает событие в очередь после неудачной попытки
+func (r *OutboxRepository) RetryWebhookEvent(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	query := `UPDATE webhook_outbox SET status = 'pending', next_attempt_at = $2, locked_until = NULL, last_error = $3
+		WHERE id = $1 AND status = 'delivering'`
+	if _, err := r.db.ExecContext(ctx, query, id, nextAttemptAt, lastError); err != nil {
+		return fmt.Errorf("failed to reschedule webhook event: %w", err)
+	}
+	return nil
+}
+
+// MarkWebhookDead больше не пытаемся доставить событие (dead letter)
+func (r *OutboxRepository) MarkWebhookDead(ctx context.Context, id int64, lastError string) error {
+	query := `UPDATE webhook_outbox SET status = 'dead', locked_until = NULL, last_error = $2
+		WHERE id = $1 AND status = 'delivering'`
+	if _, err := r.db.ExecContext(ctx, query, id, lastError); err != nil {
+		return fmt.Errorf("failed to mark webhook event as dead: %w", err)
+	}
+	return nil
+}
+
+// ReleaseWebhookEvent возвращает событие в очередь без учета попытки (доставка прервана остановкой сервиса)
+func (r *OutboxRepository) ReleaseWebhookEvent(ctx context.Context, id int64) error {
+	query := `UPDATE webhook_outbox SET status = 'pending', attempts = GREATEST(attempts - 1, 0), locked_until = NULL
+		WHERE id = $1 AND status = 'delivering'`
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to release webhook event: %w", err)
+	}
+	return nil
+}
+
+func scanOutboxEvent(row rowScanner) (*models.OutboxEvent, error) {
+	var (
+		event       models.OutboxEvent
+		payload     []byte
+		lockedUntil sql.NullTime
+		lastError   sql.NullString
+		deliveredAt sql.NullTime
+	)
+	err := row.Scan(
+		&event.ID,
+		&event.EventID,
+		&event.EventType,
+		&payload,
+		&event.Status,
+		&event.Attempts,
+		&event.NextAttemptAt,
+		&lockedUntil,
+		&lastError,
+		&event.CreatedAt,
+		&deliveredAt,
+		&event.TraceParent,
+		&event.EndpointID,
+		&event.EndpointURL,
+		&event.EndpointSecret,
+		&event.EndpointFormat,
+	)
+	if err != nil {
+		return nil, err
+	}
+	event.Payload = payload
+	if lockedUntil.Valid {
+		event.LockedUntil = &lockedUntil.Time
+	}
+	if lastError.Valid {
+		event.LastError = &lastError.String
+	}
+	if deliveredAt.Valid {
+		event.DeliveredAt = &deliveredAt.Time
+	}
+	return &event, nil
+}
@@ -3,12 +3,16 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/rryowa/medods_dvortsov/internal/models"
 	"github.com/rryowa/medods_dvortsov/internal/storage"
 )
 
+const sessionColumns = `id, user_id, selector, verifier_hash, client_ip, user_agent, expires_at, created_at, access_token_jti, status, refreshed_at, family_id`
+
 type SessionRepository struct {
 	db storage.DBTX
 }
@@ -18,7 +22,7 @@ func NewSessionRepository(db storage.DBTX) *SessionRepository {
 }
 
 func (r *SessionRepository) CreateSession(ctx context.Context, session models.RefreshSession) (int64, error) {
-	query := `INSERT INTO sessions (user_id, selector, verifier_hash, client_ip, user_agent, expires_at, created_at, access_token_jti) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	query := `INSERT INTO sessions (user_id, selector, verifier_hash, client_ip, user_agent, expires_at, created_at, access_token_jti, refreshed_at, family_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
 	var id int64
 	err := r.db.QueryRowContext(
 		ctx,
@@ -31,6 +35,8 @@ func (r *SessionRepository) CreateSession(ctx context.Context, session models.Re
 		session.ExpiresAt,
 		session.CreatedAt,
 		session.AccessTokenJTI,
+		session.RefreshedAt,
+		session.FamilyID,
 	).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert session: %w", err)
@@ -42,78 +48,244 @@ func (r *SessionRepository) GetActiveSessionBySelector(
 	ctx context.Context,
 	selector string,
 ) (*models.RefreshSession, error) {
-	var session models.RefreshSession
-	query := `SELECT id, user_id, selector, verifier_hash, client_ip, user_agent, expires_at, created_at, access_token_jti FROM sessions WHERE selector = $1 AND status = 'active'`
-	err := r.db.QueryRowContext(ctx, query, selector).Scan(
-		&session.ID,
-		&session.UserID,
-		&session.Selector,
-		&session.VerifierHash,
-		&session.IPAddress,
-		&session.UserAgent,
-		&session.ExpiresAt,
-		&session.CreatedAt,
-		&session.AccessTokenJTI,
-	)
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE selector = $1 AND status = 'active' AND expires_at > NOW()`
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, selector))
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("session with selector %s not found: %w", selector, storage.ErrSessionNotFound)
 		}
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
-	return &session, nil
+	return session, nil
 }
 
 func (r *SessionRepository) FindSessionBySelector(
 	ctx context.Context,
 	selector string,
 ) (*models.RefreshSession, error) {
-	var session models.RefreshSession
-	query := `SELECT id, user_id, selector, verifier_hash, client_ip, user_agent, expires_at, created_at, access_token_jti FROM sessions WHERE selector = $1`
-	err := r.db.QueryRowContext(ctx, query, selector).Scan(
-		&session.ID,
-		&session.UserID,
-		&session.Selector,
-		&session.VerifierHash,
-		&session.IPAddress,
-		&session.UserAgent,
-		&session.ExpiresAt,
-		&session.CreatedAt,
-		&session.AccessTokenJTI,
-	)
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE selector = $1`
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, selector))
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("session with selector %s not found: %w", selector, storage.ErrSessionNotFound)
 		}
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
-	return &session, nil
+	return session, nil
+}
+
+// ListActiveUserSessions возвращает живые сессии пользователя, новые сверху
+func (r *SessionRepository) ListActiveUserSessions(
+	ctx context.Context,
+	userID int64,
+	now time.Time,
+) ([]models.RefreshSession, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE user_id = $1 AND status = 'active' AND expires_at > $2 ORDER BY COALESCE(refreshed_at, created_at) DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.RefreshSession
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return sessions, nil
+}
+
+func (r *SessionRepository) GetUserSessionByID(
+	ctx context.Context,
+	userID, sessionID int64,
+) (*models.RefreshSession, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1 AND user_id = $2`
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, sessionID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("session %d not found: %w", sessionID, storage.ErrSessionNotFound)
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return session, nil
 }
 
 // MarkSessionAsUsed помечает сессию как использованную.
+// Условие на status делает ротацию атомарной: из параллельных запросов с одним
+// refresh-токеном сессию пометит только один, остальные получат ErrSessionAlreadyUsed.
 func (r *SessionRepository) MarkSessionAsUsed(ctx context.Context, selector string) error {
-	query := `UPDATE sessions SET status = 'used' WHERE selector = $1`
-	_, err := r.db.ExecContext(ctx, query, selector)
+	query := `UPDATE sessions SET status = 'used', used_at = NOW() WHERE selector = $1 AND status = 'active'`
+	res, err := r.db.ExecContext(ctx, query, selector)
 	if err != nil {
 		return fmt.Errorf("failed to mark session as used: %w", err)
 	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrSessionAlreadyUsed
+	}
 	return nil
 }
 
-func (r *SessionRepository) DeleteSession(ctx context.Context, selector string) error {
-	query := `DELETE FROM sessions WHERE selector = $1`
-	_, err := r.db.ExecContext(ctx, query, selector)
+// DeleteSession удаляет активную сессию. Использованные сессии остаются до очистки:
+// по ним обнаруживается повторное использование refresh-токенов семейства.
+// Возвращает false, если активной сессии с таким selector нет.
+func (r *SessionRepository) DeleteSession(ctx context.Context, selector string) (bool, error) {
+	query := `DELETE FROM sessions WHERE selector = $1 AND status = 'active'`
+	res, err := r.db.ExecContext(ctx, query, selector)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
+// DeleteSessionByAccessTokenJTI удаляет активную сессию, к которой привязан access-токен.
+// Использованные сессии того же семейства остаются для обнаружения повторного использования.
+func (r *SessionRepository) DeleteSessionByAccessTokenJTI(ctx context.Context, jti string) (string, error) {
+	query := `DELETE FROM sessions WHERE access_token_jti = $1 AND status = 'active' RETURNING family_id`
+	var familyID string
+	err := r.db.QueryRowContext(ctx, query, jti).Scan(&familyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to delete session by access token jti: %w", err)
+	}
+	return familyID, nil
+}
+
+// DeleteUserSession удаляет активную сессию, только если она принадлежит пользователю
+func (r *SessionRepository) DeleteUserSession(ctx context.Context, userID, sessionID int64) error {
+	query := `DELETE FROM sessions WHERE id = $1 AND user_id = $2 AND status = 'active'`
+	res, err := r.db.ExecContext(ctx, query, sessionID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to delete session: %w", err)
+		return fmt.Errorf("failed to delete user session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("session %d not found: %w", sessionID, storage.ErrSessionNotFound)
 	}
 	return nil
 }
 
-func (r *SessionRepository) DeleteAllUserSessions(ctx context.Context, userID int64) error {
+// DeleteSessionFamily удаляет всю цепочку ротаций одного логина
+func (r *SessionRepository) DeleteSessionFamily(ctx context.Context, familyID string) (int64, error) {
+	query := `DELETE FROM sessions WHERE family_id = $1`
+	res, err := r.db.ExecContext(ctx, query, familyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete session family: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// DeleteStaleSessions удаляет до limit сессий, истекших или использованных раньше cutoff
+func (r *SessionRepository) DeleteStaleSessions(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	query := `DELETE FROM sessions WHERE id IN (
+		SELECT id FROM sessions
+		WHERE expires_at < $1 OR (status = 'used' AND used_at < $1)
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	)`
+	res, err := r.db.ExecContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale sessions: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return n, nil
+}
+
+func (r *SessionRepository) DeleteAllUserSessions(ctx context.Context, userID int64) (int64, error) {
 	query := `DELETE FROM sessions WHERE user_id = $1`
-	_, err := r.db.ExecContext(ctx, query, userID)
+	res, err := r.db.ExecContext(ctx, query, userID)
 	if err != nil {
-		return fmt.Errorf("delete user sessions: %w", err)
+		return 0, fmt.Errorf("delete user sessions: %w", err)
 	}
-	return nil
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// revokeSessions удаляет сессии target, см. models.RevokeTarget
+func (r *SessionRepository) revokeSessions(ctx context.Context, target models.RevokeTarget) (models.RevokeResult, error) {
+	var (
+		result models.RevokeResult
+		err    error
+	)
+	switch {
+	case target.AccessTokenJTI != "":
+		result.FamilyID, err = r.DeleteSessionByAccessTokenJTI(ctx, target.AccessTokenJTI)
+		if result.FamilyID != "" {
+			result.Deleted = 1
+		}
+	case target.Selector != "":
+		var deleted bool
+		deleted, err = r.DeleteSession(ctx, target.Selector)
+		if deleted {
+			result.Deleted = 1
+		}
+	case target.SessionID != 0:
+		err = r.DeleteUserSession(ctx, target.UserID, target.SessionID)
+		if err == nil {
+			result.Deleted = 1
+		}
+	case target.FamilyID != "":
+		result.Deleted, err = r.DeleteSessionFamily(ctx, target.FamilyID)
+	case target.UserID != 0:
+		result.Deleted, err = r.DeleteAllUserSessions(ctx, target.UserID)
+	default:
+		err = errors.New("empty revoke target")
+	}
+	return result, err
+}
+
+func scanSession(row rowScanner) (*models.RefreshSession, error) {
+	var (
+		session     models.RefreshSession
+		refreshedAt sql.NullTime
+	)
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Selector,
+		&session.VerifierHash,
+		&session.IPAddress,
+		&session.UserAgent,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+		&session.AccessTokenJTI,
+		&session.Status,
+		&refreshedAt,
+		&session.FamilyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if refreshedAt.Valid {
+		session.RefreshedAt = &refreshedAt.Time
+	}
+	return &session, nil
 }
@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+const signingKeyColumns = `kid, algorithm, key_material, encrypted, status, created_at, activates_at, retires_at`
+
+type SigningKeyRepository struct {
+	db storage.DBTX
+}
+
+func NewSigningKeyRepository(db storage.DBTX) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+// CreateSigningKey не добавляет ключ, если kid уже есть в связке или ключ с этим kid был удален
+// после вывода из ротации (signing_key_tombstones)
+func (r *SigningKeyRepository) CreateSigningKey(ctx context.Context, key models.StoredSigningKey) (bool, error) {
+	query := `INSERT INTO signing_keys (kid, algorithm, key_material, encrypted, status, created_at, activates_at)
+		SELECT $1::text, $2::text, $3::bytea, $4::boolean, $5::text, $6::timestamptz, $7::timestamptz
+		WHERE NOT EXISTS (SELECT 1 FROM signing_key_tombstones WHERE kid = $1::text)
+		ON CONFLICT (kid) DO NOTHING`
+	res, err := r.db.ExecContext(
+		ctx,
+		query,
+		key.KID,
+		key.Algorithm,
+		key.KeyMaterial,
+		key.Encrypted,
+		key.Status,
+		key.CreatedAt,
+		key.ActivatesAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert signing key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return n == 1, nil
+}
+
+// IsSigningKeyTombstoned сообщает, был ли ключ kid удален после вывода из ротации
+func (r *SigningKeyRepository) IsSigningKeyTombstoned(ctx context.Context, kid string) (bool, error) {
+	var tombstoned bool
+	query := `SELECT EXISTS (SELECT 1 FROM signing_key_tombstones WHERE kid = $1)`
+	if err := r.db.QueryRowContext(ctx, query, kid).Scan(&tombstoned); err != nil {
+		return false, fmt.Errorf("failed to check signing key tombstone: %w", err)
+	}
+	return tombstoned, nil
+}
+
+// EncryptSigningKey заменяет незашифрованный материал ключа зашифрованным
+func (r *SigningKeyRepository) EncryptSigningKey(ctx context.Context, kid string, sealed []byte) error {
+	query := `UPDATE signing_keys SET key_material = $2, encrypted = TRUE WHERE kid = $1 AND NOT encrypted`
+	if _, err := r.db.ExecContext(ctx, query, kid, sealed); err != nil {
+		return fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+	return nil
+}
+
+func (r *SigningKeyRepository) GetSigningKey(ctx context.Context, kid string) (*models.StoredSigningKey, error) {
+	query := `SELECT ` + signingKeyColumns + ` FROM signing_keys WHERE kid = $1`
+	key, err := scanSigningKey(r.db.QueryRowContext(ctx, query, kid))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get signing key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *SigningKeyRepository) ListSigningKeys(ctx context.Context, now time.Time) ([]models.StoredSigningKey, error) {
+	query := `SELECT ` + signingKeyColumns + ` FROM signing_keys WHERE retires_at IS NULL OR retires_at > $1 ORDER BY activates_at`
+	return r.listSigningKeys(ctx, query, now)
+}
+
+func (r *SigningKeyRepository) ListDueSigningKeys(ctx context.Context, now time.Time) ([]models.StoredSigningKey, error) {
+	query := `SELECT ` + signingKeyColumns + ` FROM signing_keys WHERE status = 'pending' AND activates_at <= $1 ORDER BY activates_at`
+	return r.listSigningKeys(ctx, query, now)
+}
+
+// DeleteRetiredSigningKeys удаляет выведенные ключи, которые больше не проверяют токены.
+// kid остается в signing_key_tombstones: ключ из конфигурации с этим kid не вернется в связку.
+func (r *SigningKeyRepository) DeleteRetiredSigningKeys(ctx context.Context, now time.Time) (int64, error) {
+	query := `WITH deleted AS (
+		DELETE FROM signing_keys WHERE status = 'retired' AND retires_at <= $1 RETURNING kid
+	) INSERT INTO signing_key_tombstones (kid, deleted_at) SELECT kid, $1 FROM deleted ON CONFLICT (kid) DO NOTHING`
+	res, err := r.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("delete retired signing keys: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// retireActiveSigningKey переводит текущий активный ключ в режим только проверки
+func (r *SigningKeyRepository) retireActiveSigningKey(ctx context.Context, retireAt time.Time) error {
+	query := `UPDATE signing_keys SET status = 'retired', retires_at = $1 WHERE status = 'active'`
+	if _, err := r.db.ExecContext(ctx, query, retireAt); err != nil {
+		return fmt.Errorf("failed to retire active signing key: %w", err)
+	}
+	return nil
+}
+
+func (r *SigningKeyRepository) activateSigningKey(ctx context.Context, kid string, now time.Time) error {
+	query := `UPDATE signing_keys SET status = 'active', activates_at = $2, retires_at = NULL WHERE kid = $1`
+	res, err := r.db.ExecContext(ctx, query, kid, now)
+	if err != nil {
+		return fmt.Errorf("failed to activate signing key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrKeyNotFound
+	}
+	return nil
+}
+
+func (r *SigningKeyRepository) listSigningKeys(
+	ctx context.Context,
+	query string,
+	args ...interface{},
+) ([]models.StoredSigningKey, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.StoredSigningKey
+	for rows.Next() {
+		key, err := scanSigningKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan signing key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return keys, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSigningKey(row rowScanner) (*models.StoredSigningKey, error) {
+	var (
+		key       models.StoredSigningKey
+		retiresAt sql.NullTime
+	)
+	err := row.Scan(
+		&key.KID,
+		&key.Algorithm,
+		&key.KeyMaterial,
+		&key.Encrypted,
+		&key.Status,
+		&key.CreatedAt,
+		&key.ActivatesAt,
+		&retiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if retiresAt.Valid {
+		key.RetiresAt = &retiresAt.Time
+	}
+	return &key, nil
+}
@@ -6,27 +6,51 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/rryowa/medods_dvortsov/internal/models"
 	"github.com/rryowa/medods_dvortsov/internal/storage"
 )
 
+// signingKeysLockID ключ advisory lock для изменений key ring:
+// реплики не должны одновременно ротировать ключи
+const signingKeysLockID = 7001
+
 type Storage struct {
 	db *sql.DB
 	*UserRepository
 	*SessionRepository
+	*SigningKeyRepository
+	*OutboxRepository
+	*WebhookEndpointRepository
+	*AuditRepository
+	*APIKeyRepository
 }
 
 func NewStorage(db *sql.DB) *Storage {
 	return &Storage{
-		db:                db,
-		UserRepository:    NewUserRepository(db),
-		SessionRepository: NewSessionRepository(db),
+		db:                   db,
+		UserRepository:       NewUserRepository(db),
+		SessionRepository:    NewSessionRepository(db),
+		SigningKeyRepository: NewSigningKeyRepository(db),
+		OutboxRepository:     NewOutboxRepository(db),
+
+		WebhookEndpointRepository: NewWebhookEndpointRepository(db),
+		AuditRepository:           NewAuditRepository(db),
+		APIKeyRepository:          NewAPIKeyRepository(db),
 	}
 }
 
 // IssueTokensTx выполняет транзакцию по выпуску токенов
-func (s *Storage) IssueTokensTx(ctx context.Context, guid string, session models.RefreshSession) (*models.User, error) {
+// events строит события для webhook outbox, когда пользователь уже известен (может быть создан в этой транзакции)
+// audit получает ID пользователя и созданной сессии
+func (s *Storage) IssueTokensTx(
+	ctx context.Context,
+	guid string,
+	session models.RefreshSession,
+	events func(user *models.User) ([]models.OutboxEvent, error),
+	audit models.AuditEvent,
+) (*models.User, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("begin transaction: %w", err)
@@ -39,6 +63,7 @@ func (s *Storage) IssueTokensTx(ctx context.Context, guid string, session models
 
 	userRepoTx := NewUserRepository(tx)
 	sessionRepoTx := NewSessionRepository(tx)
+	outboxRepoTx := NewOutboxRepository(tx)
 
 	user, err := userRepoTx.GetUserByGUID(ctx, guid)
 	if err != nil {
@@ -53,11 +78,27 @@ func (s *Storage) IssueTokensTx(ctx context.Context, guid string, session models
 	}
 
 	session.UserID = user.ID
-	_, err = sessionRepoTx.CreateSession(ctx, session)
+	sessionID, err := sessionRepoTx.CreateSession(ctx, session)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session in tx: %w", err)
 	}
 
+	outboxEvents, err := events(user)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range outboxEvents {
+		if err := outboxRepoTx.EnqueueWebhookEvent(ctx, event); err != nil {
+			return nil, fmt.Errorf("failed to enqueue webhook event in tx: %w", err)
+		}
+	}
+
+	audit.UserID = &user.ID
+	audit.SessionID = &sessionID
+	if err := NewAuditRepository(tx).appendAuditEvent(ctx, audit); err != nil {
+		return nil, fmt.Errorf("failed to append audit event in tx: %w", err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
@@ -66,12 +107,15 @@ func (s *Storage) IssueTokensTx(ctx context.Context, guid string, session models
 }
 
 // RotateTokensTx выполняет транзакцию по ротации refresh-токенов
-// Старая сессия помечается как 'used', создается новая
+// Старая сессия помечается как 'used', создается новая, events пишутся в webhook outbox
+// Если старую сессию уже ротировал параллельный запрос - storage.ErrSessionAlreadyUsed
 func (s *Storage) RotateTokensTx(
 	ctx context.Context,
 	oldSelector string,
 	newSession models.RefreshSession,
 	userID int64,
+	events []models.OutboxEvent,
+	audit models.AuditEvent,
 ) (*models.User, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -85,13 +129,15 @@ func (s *Storage) RotateTokensTx(
 
 	sessionRepoTx := NewSessionRepository(tx)
 	userRepoTx := NewUserRepository(tx)
+	outboxRepoTx := NewOutboxRepository(tx)
 
 	if err := sessionRepoTx.MarkSessionAsUsed(ctx, oldSelector); err != nil {
 		return nil, fmt.Errorf("failed to mark session as used in tx: %w", err)
 	}
 
 	newSession.UserID = userID
-	if _, err := sessionRepoTx.CreateSession(ctx, newSession); err != nil {
+	sessionID, err := sessionRepoTx.CreateSession(ctx, newSession)
+	if err != nil {
 		return nil, fmt.Errorf("failed to create new session in tx: %w", err)
 	}
 
@@ -100,9 +146,371 @@ func (s *Storage) RotateTokensTx(
 		return nil, fmt.Errorf("failed to get user by id in tx: %w", err)
 	}
 
+	for _, event := range events {
+		if err := outboxRepoTx.EnqueueWebhookEvent(ctx, event); err != nil {
+			return nil, fmt.Errorf("failed to enqueue webhook event in tx: %w", err)
+		}
+	}
+
+	audit.UserID = &userID
+	audit.SessionID = &sessionID
+	if err := NewAuditRepository(tx).appendAuditEvent(ctx, audit); err != nil {
+		return nil, fmt.Errorf("failed to append audit event in tx: %w", err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
 
 	return user, nil
 }
+
+// RevokeSessionsTx удаляет сессии и в той же транзакции пишет события в outbox и запись аудита
+// (logout, отзыв, обнаружение кражи). events получает результат удаления: события об отзыве
+// пишутся, только если что-то удалено. audit nil - без записи аудита.
+func (s *Storage) RevokeSessionsTx(
+	ctx context.Context,
+	target models.RevokeTarget,
+	events func(result models.RevokeResult) ([]models.OutboxEvent, error),
+	audit *models.AuditEvent,
+) (models.RevokeResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.RevokeResult{}, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", rerr)
+		}
+	}()
+
+	outboxRepoTx := NewOutboxRepository(tx)
+
+	result, err := NewSessionRepository(tx).revokeSessions(ctx, target)
+	if err != nil {
+		return models.RevokeResult{}, fmt.Errorf("failed to revoke sessions in tx: %w", err)
+	}
+
+	outboxEvents, err := events(result)
+	if err != nil {
+		return models.RevokeResult{}, err
+	}
+	for _, event := range outboxEvents {
+		if err := outboxRepoTx.EnqueueWebhookEvent(ctx, event); err != nil {
+			return models.RevokeResult{}, fmt.Errorf("failed to enqueue webhook event in tx: %w", err)
+		}
+	}
+
+	if audit != nil {
+		if err := NewAuditRepository(tx).appendAuditEvent(ctx, *audit); err != nil {
+			return models.RevokeResult{}, fmt.Errorf("failed to append audit event in tx: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.RevokeResult{}, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// LinkAuditEventsTx связывает в цепочку до limit несвязанных записей аудита в порядке id
+// и возвращает их число. Если связывание идет на другой реплике - 0 без ожидания, см. auditChainLockID
+func (s *Storage) LinkAuditEventsTx(ctx context.Context, limit int) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", rerr)
+		}
+	}()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, auditChainLockID).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to acquire audit chain lock: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	auditRepoTx := NewAuditRepository(tx)
+	head, err := auditRepoTx.GetAuditChainHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+	events, err := auditRepoTx.listUnlinkedAuditEvents(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	prevHash := models.GenesisAuditHash()
+	if head.ChainSeq != 0 {
+		prevHash = head.Hash
+	}
+	for _, event := range events {
+		hash, err := event.ChainHash(prevHash)
+		if err != nil {
+			return 0, err
+		}
+		event.PrevHash, event.Hash, event.ChainSeq = prevHash, hash, head.ChainSeq+1
+		if err := auditRepoTx.linkAuditEvent(ctx, event); err != nil {
+			return 0, err
+		}
+		head = models.AuditChainHead{ChainSeq: event.ChainSeq, EventID: event.ID, Hash: hash}
+		prevHash = hash
+	}
+	if err := auditRepoTx.setAuditChainHead(ctx, head); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return len(events), nil
+}
+
+// SyncWebhookEndpointTx добавляет endpoint из конфигурации (или обновляет его секрет и формат).
+// Подписки выдаются только новому endpoint'у, чтобы не затирать измененные через API.
+// Недоставленные события, записанные до появления таблицы endpoint'ов, достаются ему же:
+// раньше все события уходили на WEBHOOK_URL.
+func (s *Storage) SyncWebhookEndpointTx(
+	ctx context.Context,
+	url, secret, format string,
+	eventTypes []string,
+) (bool, int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", rerr)
+		}
+	}()
+
+	endpointRepoTx := NewWebhookEndpointRepository(tx)
+
+	id, created, err := endpointRepoTx.upsertWebhookEndpoint(ctx, url, secret, format)
+	if err != nil {
+		return false, 0, err
+	}
+	if created {
+		if err := endpointRepoTx.addWebhookSubscriptions(ctx, id, eventTypes); err != nil {
+			return false, 0, err
+		}
+	}
+	adopted, err := NewOutboxRepository(tx).adoptOrphanedWebhookEvents(ctx, id)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return created, adopted, nil
+}
+
+// CreateWebhookEndpointTx создает endpoint вместе с подписками
+func (s *Storage) CreateWebhookEndpointTx(
+	ctx context.Context,
+	endpoint models.WebhookEndpoint,
+) (*models.WebhookEndpoint, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", rerr)
+		}
+	}()
+
+	endpointRepoTx := NewWebhookEndpointRepository(tx)
+
+	id, err := endpointRepoTx.createWebhookEndpoint(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := endpointRepoTx.addWebhookSubscriptions(ctx, id, endpoint.Events); err != nil {
+		return nil, err
+	}
+	created, err := endpointRepoTx.GetWebhookEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// UpdateWebhookEndpointTx меняет переданные поля endpoint'а, подписки заменяются целиком
+func (s *Storage) UpdateWebhookEndpointTx(
+	ctx context.Context,
+	id int64,
+	update models.WebhookEndpointUpdate,
+) (*models.WebhookEndpoint, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", rerr)
+		}
+	}()
+
+	endpointRepoTx := NewWebhookEndpointRepository(tx)
+
+	if err := endpointRepoTx.updateWebhookEndpoint(ctx, id, update); err != nil {
+		return nil, err
+	}
+	if update.Events != nil {
+		if err := endpointRepoTx.deleteWebhookSubscriptions(ctx, id); err != nil {
+			return nil, err
+		}
+		if err := endpointRepoTx.addWebhookSubscriptions(ctx, id, update.Events); err != nil {
+			return nil, err
+		}
+	}
+	updated, err := endpointRepoTx.GetWebhookEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+// PromoteSigningKeyTx выполняет транзакцию по смене ключа подписи
+// Прежний активный ключ становится 'retired' и проверяет токены до retireAt
+func (s *Storage) PromoteSigningKeyTx(ctx context.Context, kid string, now, retireAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", rerr)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, signingKeysLockID); err != nil {
+		return fmt.Errorf("failed to acquire signing keys lock: %w", err)
+	}
+
+	keyRepoTx := NewSigningKeyRepository(tx)
+
+	key, err := keyRepoTx.GetSigningKey(ctx, kid)
+	if err != nil {
+		return fmt.Errorf("failed to get signing key in tx: %w", err)
+	}
+	if key.Status == models.SigningKeyStatusActive {
+		// Другая реплика уже сделала ключ активным
+		return nil
+	}
+
+	if err := keyRepoTx.retireActiveSigningKey(ctx, retireAt); err != nil {
+		return fmt.Errorf("failed to retire signing key in tx: %w", err)
+	}
+	if err := keyRepoTx.activateSigningKey(ctx, kid, now); err != nil {
+		return fmt.Errorf("failed to activate signing key in tx: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ScheduleSigningKeyTx выполняет транзакцию по добавлению следующего ключа подписи
+// Ключ публикуется в JWKS сразу, а подписывать начинает с key.ActivatesAt
+func (s *Storage) ScheduleSigningKeyTx(
+	ctx context.Context,
+	key models.StoredSigningKey,
+	rotateBefore time.Time,
+) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", rerr)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, signingKeysLockID); err != nil {
+		return false, fmt.Errorf("failed to acquire signing keys lock: %w", err)
+	}
+
+	var due bool
+	query := `SELECT NOT EXISTS (
+		SELECT 1 FROM signing_keys
+		WHERE status = 'pending' OR (status = 'active' AND activates_at > $1)
+	)`
+	if err := tx.QueryRowContext(ctx, query, rotateBefore).Scan(&due); err != nil {
+		return false, fmt.Errorf("failed to check signing key rotation: %w", err)
+	}
+	if !due {
+		return false, nil
+	}
+
+	if _, err := NewSigningKeyRepository(tx).CreateSigningKey(ctx, key); err != nil {
+		return false, fmt.Errorf("failed to create signing key in tx: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// RotateAPIKeyTx выполняет транзакцию ротации API-ключа: новый ключ появляется
+// одновременно с ограничением срока прежнего
+func (s *Storage) RotateAPIKeyTx(
+	ctx context.Context,
+	oldID int64,
+	newKey models.APIKey,
+	oldExpiresAt time.Time,
+) (*models.APIKey, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			log.Printf("failed to rollback transaction: %v", rerr)
+		}
+	}()
+
+	apiKeyRepoTx := NewAPIKeyRepository(tx)
+
+	if err := apiKeyRepoTx.ExpireAPIKey(ctx, oldID, oldExpiresAt, newKey.CreatedAt); err != nil {
+		return nil, err
+	}
+	created, err := apiKeyRepoTx.CreateAPIKey(ctx, newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+const (
+	webhookEndpointColumns = `e.id, e.url, e.secret, e.description, e.enabled, e.format, e.created_at, e.updated_at,
+	COALESCE((SELECT array_agg(s.event_type ORDER BY s.event_type) FROM webhook_subscriptions s WHERE s.endpoint_id = e.id), '{}')`
+	webhookDeliveryColumns = `id, endpoint_id, outbox_id, event_id, event_type, attempt, success, status_code, latency_ms,
+	response_snippet, error, created_at`
+
+	pqUniqueViolation = "23505"
+)
+
+type WebhookEndpointRepository struct {
+	db storage.DBTX
+}
+
+func NewWebhookEndpointRepository(db storage.DBTX) *WebhookEndpointRepository {
+	return &WebhookEndpointRepository{db: db}
+}
+
+func (r *WebhookEndpointRepository) GetWebhookEndpoint(ctx context.Context, id int64) (*models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints e WHERE e.id = $1`
+	endpoint, err := scanWebhookEndpoint(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrWebhookEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+func (r *WebhookEndpointRepository) ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints e ORDER BY e.id`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []models.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, *endpoint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return endpoints, nil
+}
+
+func (r *WebhookEndpointRepository) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+func (r *WebhookEndpointRepository) CreateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries
+		(endpoint_id, outbox_id, event_id, event_type, attempt, success, status_code, latency_ms, response_snippet, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		delivery.EndpointID,
+		delivery.OutboxID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Attempt,
+		delivery.Success,
+		delivery.StatusCode,
+		delivery.Latency.Milliseconds(),
+		delivery.ResponseSnippet,
+		delivery.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookEndpointRepository) ListWebhookDeliveries(
+	ctx context.Context,
+	endpointID int64,
+	limit int,
+) ([]models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE endpoint_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, endpointID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookEndpointRepository) createWebhookEndpoint(
+	ctx context.Context,
+	endpoint models.WebhookEndpoint,
+) (int64, error) {
+	query := `INSERT INTO webhook_endpoints (url, secret, description, enabled, format) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	var id int64
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		endpoint.URL,
+		endpoint.Secret,
+		endpoint.Description,
+		endpoint.Enabled,
+		endpoint.Format,
+	).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, storage.ErrWebhookEndpointExists
+		}
+		return 0, fmt.Errorf("failed to insert webhook endpoint: %w", err)
+	}
+	return id, nil
+}
+
+func (r *WebhookEndpointRepository) updateWebhookEndpoint(
+	ctx context.Context,
+	id int64,
+	update models.WebhookEndpointUpdate,
+) error {
+	query := `UPDATE webhook_endpoints SET
+		url = COALESCE($2, url),
+		secret = COALESCE($3, secret),
+		description = COALESCE($4, description),
+		enabled = COALESCE($5, enabled),
+		format = COALESCE($6, format),
+		updated_at = NOW()
+		WHERE id = $1`
+	res, err := r.db.ExecContext(
+		ctx,
+		query,
+		id,
+		update.URL,
+		update.Secret,
+		update.Description,
+		update.Enabled,
+		update.Format,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrWebhookEndpointExists
+		}
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+// upsertWebhookEndpoint создает endpoint или обновляет секрет и формат существующего с тем же URL.
+// created = true, если endpoint новый (xmax = 0 только у вставленной строки).
+func (r *WebhookEndpointRepository) upsertWebhookEndpoint(
+	ctx context.Context,
+	url, secret, format string,
+) (id int64, created bool, err error) {
+	query := `INSERT INTO webhook_endpoints (url, secret, format) VALUES ($1, $2, $3)
+		ON CONFLICT (url) DO UPDATE SET secret = EXCLUDED.secret, format = EXCLUDED.format, updated_at = NOW()
+		RETURNING id, (xmax = 0)`
+	if err := r.db.QueryRowContext(ctx, query, url, secret, format).Scan(&id, &created); err != nil {
+		return 0, false, fmt.Errorf("failed to upsert webhook endpoint: %w", err)
+	}
+	return id, created, nil
+}
+
+func (r *WebhookEndpointRepository) addWebhookSubscriptions(
+	ctx context.Context,
+	endpointID int64,
+	eventTypes []string,
+) error {
+	query := `INSERT INTO webhook_subscriptions (endpoint_id, event_type)
+		SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, endpointID, pq.Array(eventTypes)); err != nil {
+		return fmt.Errorf("failed to add webhook subscriptions: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookEndpointRepository) deleteWebhookSubscriptions(ctx context.Context, endpointID int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE endpoint_id = $1`, endpointID); err != nil {
+		return fmt.Errorf("failed to delete webhook subscriptions: %w", err)
+	}
+	return nil
+}
+
+func scanWebhookEndpoint(row rowScanner) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := row.Scan(
+		&endpoint.ID,
+		&endpoint.URL,
+		&endpoint.Secret,
+		&endpoint.Description,
+		&endpoint.Enabled,
+		&endpoint.Format,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+		pq.Array(&endpoint.Events),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var (
+		delivery   models.WebhookDelivery
+		outboxID   sql.NullInt64
+		statusCode sql.NullInt64
+		latencyMS  int64
+	)
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.EndpointID,
+		&outboxID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Attempt,
+		&delivery.Success,
+		&statusCode,
+		&latencyMS,
+		&delivery.ResponseSnippet,
+		&delivery.Error,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if outboxID.Valid {
+		delivery.OutboxID = &outboxID.Int64
+	}
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		delivery.StatusCode = &code
+	}
+	delivery.Latency = time.Duration(latencyMS) * time.Millisecond
+	return &delivery, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation
+}
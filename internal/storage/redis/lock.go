@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const lockPrefix = "lock:"
+
+type Locker struct {
+	client *redis.Client
+	// Снимаем блокировку, только если она все еще наша (могла истечь и достаться другой реплике)
+	unlockScript *redis.Script
+}
+
+func NewLocker(client *redis.Client) *Locker {
+	return &Locker{
+		client: client,
+		unlockScript: redis.NewScript(`
+			if redis.call("GET", KEYS[1]) == ARGV[1] then
+				return redis.call("DEL", KEYS[1])
+			end
+			return 0
+		`),
+	}
+}
+
+func (l *Locker) TryLock(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	token := uuid.NewString()
+	err := l.client.SetArgs(ctx, lockPrefix+key, token, redis.SetArgs{Mode: "NX", TTL: ttl}).Err()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("redis set nx: %w", err)
+	}
+	return token, nil
+}
+
+func (l *Locker) Unlock(ctx context.Context, key, token string) error {
+	if err := l.unlockScript.Run(ctx, l.client, []string{lockPrefix + key}, token).Err(); err != nil {
+		return fmt.Errorf("redis unlock: %w", err)
+	}
+	return nil
+}
@@ -9,6 +9,13 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// denylistPrefix ключи denylist - JTI access-токенов, а не сами токены:
+// так токен можно отозвать и по сессии, не имея его на руках
+const (
+	denylistPrefix = "denylist:"
+	rotationPrefix = "rotation:"
+)
+
 type TokenStorage struct {
 	client *redis.Client
 }
@@ -17,16 +24,16 @@ func NewTokenStorage(client *redis.Client) *TokenStorage {
 	return &TokenStorage{client: client}
 }
 
-func (s *TokenStorage) InvalidateToken(ctx context.Context, token string, expiration time.Duration) error {
-	if err := s.client.Set(ctx, token, "invalidated", expiration).Err(); err != nil {
+func (s *TokenStorage) InvalidateToken(ctx context.Context, jti string, expiration time.Duration) error {
+	if err := s.client.Set(ctx, denylistPrefix+jti, "invalidated", expiration).Err(); err != nil {
 		return fmt.Errorf("redis set: %w", err)
 	}
 	return nil
 }
 
-// IsTokenInvalidated проверяет наличие токена в Redis
-func (s *TokenStorage) IsTokenInvalidated(ctx context.Context, token string) (bool, error) {
-	result, err := s.client.Get(ctx, token).Result()
+// IsTokenInvalidated проверяет наличие JTI в Redis
+func (s *TokenStorage) IsTokenInvalidated(ctx context.Context, jti string) (bool, error) {
+	result, err := s.client.Get(ctx, denylistPrefix+jti).Result()
 	if errors.Is(err, redis.Nil) {
 		return false, nil
 	} else if err != nil {
@@ -34,3 +41,25 @@ func (s *TokenStorage) IsTokenInvalidated(ctx context.Context, token string) (bo
 	}
 	return result == "invalidated", nil
 }
+
+func (s *TokenStorage) SaveRotationResult(
+	ctx context.Context,
+	selector string,
+	sealed []byte,
+	ttl time.Duration,
+) error {
+	if err := s.client.Set(ctx, rotationPrefix+selector, sealed, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
+	return nil
+}
+
+func (s *TokenStorage) GetRotationResult(ctx context.Context, selector string) ([]byte, error) {
+	result, err := s.client.Get(ctx, rotationPrefix+selector).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("redis get bytes: %w", err)
+	}
+	return result, nil
+}
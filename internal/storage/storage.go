@@ -12,6 +12,13 @@ import (
 var (
 	ErrSessionNotFound = errors.New("session not found")
 	ErrUserNotFound    = errors.New("user not found")
+	ErrKeyNotFound     = errors.New("signing key not found")
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrWebhookEndpointExists   = errors.New("webhook endpoint with this url already exists")
+	// ErrSessionAlreadyUsed сессию уже ротировал другой запрос
+	ErrSessionAlreadyUsed = errors.New("session already used")
 )
 
 type DBTX interface {
@@ -23,13 +30,56 @@ type DBTX interface {
 type Storage interface {
 	SessionRepository
 	UserRepository
-	IssueTokensTx(ctx context.Context, guid string, session models.RefreshSession) (*models.User, error)
+	SigningKeyRepository
+	OutboxRepository
+	WebhookEndpointRepository
+	AuditRepository
+	APIKeyRepository
+	// IssueTokensTx создает сессию, пишет события в outbox и запись аудита.
+	// UserID и SessionID записи аудита заполняются в транзакции.
+	IssueTokensTx(
+		ctx context.Context,
+		guid string,
+		session models.RefreshSession,
+		events func(user *models.User) ([]models.OutboxEvent, error),
+		audit models.AuditEvent,
+	) (*models.User, error)
+	// RotateTokensTx ротирует сессию, пишет события в outbox и запись аудита о новой сессии
 	RotateTokensTx(
 		ctx context.Context,
 		oldSelector string,
 		newSession models.RefreshSession,
 		userID int64,
+		events []models.OutboxEvent,
+		audit models.AuditEvent,
 	) (*models.User, error)
+	// RevokeSessionsTx удаляет сессии target, пишет события в outbox и запись аудита (если audit не nil).
+	// events вызывается в транзакции с результатом удаления.
+	RevokeSessionsTx(
+		ctx context.Context,
+		target models.RevokeTarget,
+		events func(result models.RevokeResult) ([]models.OutboxEvent, error),
+		audit *models.AuditEvent,
+	) (models.RevokeResult, error)
+	// LinkAuditEventsTx связывает в цепочку до limit записей аудита, вставленных без хэша.
+	// Возвращает число связанных; 0 - связывать нечего или связывает другая реплика
+	LinkAuditEventsTx(ctx context.Context, limit int) (int, error)
+	// PromoteSigningKeyTx делает ключ kid текущим, прежний активный ключ
+	// продолжает проверять токены до retireAt
+	PromoteSigningKeyTx(ctx context.Context, kid string, now, retireAt time.Time) error
+	// ScheduleSigningKeyTx добавляет pending-ключ, если нет другого pending-ключа
+	// и активный ключ старше rotateBefore. Возвращает false, если ротация не нужна.
+	ScheduleSigningKeyTx(ctx context.Context, key models.StoredSigningKey, rotateBefore time.Time) (bool, error)
+	// SyncWebhookEndpointTx добавляет endpoint с подписками eventTypes или обновляет секрет и формат существующего.
+	// К endpoint'у привязываются недоставленные события без получателя (остались от единственного WEBHOOK_URL).
+	// Возвращает true, если endpoint создан, и число привязанных событий.
+	SyncWebhookEndpointTx(ctx context.Context, url, secret, format string, eventTypes []string) (bool, int64, error)
+	// CreateWebhookEndpointTx создает endpoint с подписками endpoint.Events
+	CreateWebhookEndpointTx(ctx context.Context, endpoint models.WebhookEndpoint) (*models.WebhookEndpoint, error)
+	UpdateWebhookEndpointTx(ctx context.Context, id int64, update models.WebhookEndpointUpdate) (*models.WebhookEndpoint, error)
+	// RotateAPIKeyTx создает ключ newKey на смену ключа oldID, прежний ключ действует до oldExpiresAt.
+	// Отозванный или истекший oldID - ErrAPIKeyNotFound
+	RotateAPIKeyTx(ctx context.Context, oldID int64, newKey models.APIKey, oldExpiresAt time.Time) (*models.APIKey, error)
 }
 
 type UserRepository interface {
@@ -42,12 +92,87 @@ type SessionRepository interface {
 	CreateSession(ctx context.Context, session models.RefreshSession) (int64, error)
 	GetActiveSessionBySelector(ctx context.Context, selector string) (*models.RefreshSession, error)
 	FindSessionBySelector(ctx context.Context, selector string) (*models.RefreshSession, error)
+	ListActiveUserSessions(ctx context.Context, userID int64, now time.Time) ([]models.RefreshSession, error)
+	GetUserSessionByID(ctx context.Context, userID, sessionID int64) (*models.RefreshSession, error)
 	MarkSessionAsUsed(ctx context.Context, selector string) error
-	DeleteSession(ctx context.Context, selector string) error
-	DeleteAllUserSessions(ctx context.Context, userID int64) error
+	// DeleteSession удаляет активную сессию, возвращает false, если ее нет
+	DeleteSession(ctx context.Context, selector string) (bool, error)
+	// DeleteSessionByAccessTokenJTI удаляет активную сессию, к которой привязан access-токен.
+	// Использованные сессии семейства остаются: по ним обнаруживается повторное использование.
+	// Возвращает family_id удаленной сессии или "", если активной сессии уже нет.
+	DeleteSessionByAccessTokenJTI(ctx context.Context, jti string) (string, error)
+	DeleteUserSession(ctx context.Context, userID, sessionID int64) error
+	DeleteSessionFamily(ctx context.Context, familyID string) (int64, error)
+	DeleteAllUserSessions(ctx context.Context, userID int64) (int64, error)
+	DeleteStaleSessions(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+type SigningKeyRepository interface {
+	// CreateSigningKey возвращает false, если ключ с таким kid уже есть или был удален после вывода из ротации
+	CreateSigningKey(ctx context.Context, key models.StoredSigningKey) (bool, error)
+	IsSigningKeyTombstoned(ctx context.Context, kid string) (bool, error)
+	// EncryptSigningKey заменяет незашифрованный материал ключа kid зашифрованным
+	EncryptSigningKey(ctx context.Context, kid string, sealed []byte) error
+	GetSigningKey(ctx context.Context, kid string) (*models.StoredSigningKey, error)
+	// ListSigningKeys возвращает ключи, которые еще могут проверять токены
+	ListSigningKeys(ctx context.Context, now time.Time) ([]models.StoredSigningKey, error)
+	ListDueSigningKeys(ctx context.Context, now time.Time) ([]models.StoredSigningKey, error)
+	DeleteRetiredSigningKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+type OutboxRepository interface {
+	// EnqueueWebhookEvent пишет событие для каждого подписанного на его тип endpoint'а
+	EnqueueWebhookEvent(ctx context.Context, event models.OutboxEvent) error
+	// ClaimWebhookEvents забирает события для доставки и блокирует их до lockedUntil
+	ClaimWebhookEvents(ctx context.Context, now, lockedUntil time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkWebhookDelivered(ctx context.Context, id int64) error
+	RetryWebhookEvent(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error
+	MarkWebhookDead(ctx context.Context, id int64, lastError string) error
+	ReleaseWebhookEvent(ctx context.Context, id int64) error
+}
+
+type WebhookEndpointRepository interface {
+	GetWebhookEndpoint(ctx context.Context, id int64) (*models.WebhookEndpoint, error)
+	ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, id int64) error
+	CreateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	// ListWebhookDeliveries последние limit попыток доставки, новые первыми
+	ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int) ([]models.WebhookDelivery, error)
+}
+
+type AuditRepository interface {
+	ListAuditEvents(ctx context.Context, filter models.AuditFilter, limit int) ([]models.AuditEvent, error)
+	// GetAuditChainHead последняя связанная запись; ChainSeq 0 - цепочка пуста
+	GetAuditChainHead(ctx context.Context) (models.AuditChainHead, error)
+	// ListAuditChain связанные записи после afterSeq в порядке цепочки
+	ListAuditChain(ctx context.Context, afterSeq int64, limit int) ([]models.AuditEvent, error)
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey) (*models.APIKey, error)
+	GetAPIKey(ctx context.Context, id int64) (*models.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	// RevokeAPIKey возвращает ErrAPIKeyNotFound, если ключа нет или он уже отозван
+	RevokeAPIKey(ctx context.Context, id int64, now time.Time) error
+	// ExpireAPIKey сокращает срок действия ключа; отозванный или истекший ключ - ErrAPIKeyNotFound
+	ExpireAPIKey(ctx context.Context, id int64, expiresAt, now time.Time) error
+	// TouchAPIKey обновляет время последнего использования (не чаще models.APIKeyTouchInterval)
+	TouchAPIKey(ctx context.Context, id int64, now time.Time) error
+}
+
+// Locker распределенная блокировка: фоновую задачу выполняет только одна реплика
+type Locker interface {
+	// TryLock возвращает токен владельца или "", если блокировка занята
+	TryLock(ctx context.Context, key string, ttl time.Duration) (string, error)
+	Unlock(ctx context.Context, key, token string) error
 }
 
 type TokenStorage interface {
-	InvalidateToken(ctx context.Context, token string, expiration time.Duration) error
-	IsTokenInvalidated(ctx context.Context, token string) (bool, error)
+	InvalidateToken(ctx context.Context, jti string, expiration time.Duration) error
+	IsTokenInvalidated(ctx context.Context, jti string) (bool, error)
+	// SaveRotationResult сохраняет (зашифрованный) результат ротации refresh-токена selector на ttl
+	SaveRotationResult(ctx context.Context, selector string, sealed []byte, ttl time.Duration) error
+	// GetRotationResult возвращает nil, если результата нет или он истек
+	GetRotationResult(ctx context.Context, selector string) ([]byte, error)
 }
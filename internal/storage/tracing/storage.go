@@ -0,0 +1,425 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+// Storage добавляет спан на каждый вызов репозитория. Транзакции (XxxTx) - один спан на всю транзакцию:
+// внутренние запросы видны как ее длительность.
+type Storage struct {
+	next storage.Storage
+}
+
+var _ storage.Storage = (*Storage)(nil)
+
+func NewStorage(next storage.Storage) *Storage {
+	return &Storage{next: next}
+}
+
+func (s *Storage) CreateUser(ctx context.Context, guid string) (result *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "storage.CreateUser")
+	defer func() { endSpan(span, err) }()
+	return s.next.CreateUser(ctx, guid)
+}
+
+func (s *Storage) GetUserByGUID(ctx context.Context, guid string) (result *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "storage.GetUserByGUID")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetUserByGUID(ctx, guid)
+}
+
+func (s *Storage) GetUserByID(ctx context.Context, id int64) (result *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "storage.GetUserByID")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetUserByID(ctx, id)
+}
+
+func (s *Storage) CreateSession(ctx context.Context, session models.RefreshSession) (result int64, err error) {
+	ctx, span := tracer.Start(ctx, "storage.CreateSession")
+	defer func() { endSpan(span, err) }()
+	return s.next.CreateSession(ctx, session)
+}
+
+func (s *Storage) GetActiveSessionBySelector(
+	ctx context.Context,
+	selector string,
+) (result *models.RefreshSession, err error) {
+	ctx, span := tracer.Start(ctx, "storage.GetActiveSessionBySelector")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetActiveSessionBySelector(ctx, selector)
+}
+
+func (s *Storage) FindSessionBySelector(
+	ctx context.Context,
+	selector string,
+) (result *models.RefreshSession, err error) {
+	ctx, span := tracer.Start(ctx, "storage.FindSessionBySelector")
+	defer func() { endSpan(span, err) }()
+	return s.next.FindSessionBySelector(ctx, selector)
+}
+
+func (s *Storage) ListActiveUserSessions(
+	ctx context.Context,
+	userID int64,
+	now time.Time,
+) (result []models.RefreshSession, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ListActiveUserSessions")
+	defer func() { endSpan(span, err) }()
+	return s.next.ListActiveUserSessions(ctx, userID, now)
+}
+
+func (s *Storage) GetUserSessionByID(
+	ctx context.Context,
+	userID,
+	sessionID int64,
+) (result *models.RefreshSession, err error) {
+	ctx, span := tracer.Start(ctx, "storage.GetUserSessionByID")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetUserSessionByID(ctx, userID, sessionID)
+}
+
+func (s *Storage) MarkSessionAsUsed(ctx context.Context, selector string) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.MarkSessionAsUsed")
+	defer func() { endSpan(span, err) }()
+	return s.next.MarkSessionAsUsed(ctx, selector)
+}
+
+func (s *Storage) DeleteSession(ctx context.Context, selector string) (result bool, err error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteSession")
+	defer func() { endSpan(span, err) }()
+	return s.next.DeleteSession(ctx, selector)
+}
+
+func (s *Storage) DeleteSessionByAccessTokenJTI(ctx context.Context, jti string) (result string, err error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteSessionByAccessTokenJTI")
+	defer func() { endSpan(span, err) }()
+	return s.next.DeleteSessionByAccessTokenJTI(ctx, jti)
+}
+
+func (s *Storage) DeleteUserSession(ctx context.Context, userID, sessionID int64) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteUserSession")
+	defer func() { endSpan(span, err) }()
+	return s.next.DeleteUserSession(ctx, userID, sessionID)
+}
+
+func (s *Storage) DeleteSessionFamily(ctx context.Context, familyID string) (result int64, err error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteSessionFamily")
+	defer func() { endSpan(span, err) }()
+	return s.next.DeleteSessionFamily(ctx, familyID)
+}
+
+func (s *Storage) DeleteAllUserSessions(ctx context.Context, userID int64) (result int64, err error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteAllUserSessions")
+	defer func() { endSpan(span, err) }()
+	return s.next.DeleteAllUserSessions(ctx, userID)
+}
+
+func (s *Storage) DeleteStaleSessions(
+	ctx context.Context,
+	cutoff time.Time,
+	limit int,
+) (result int64, err error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteStaleSessions")
+	defer func() { endSpan(span, err) }()
+	return s.next.DeleteStaleSessions(ctx, cutoff, limit)
+}
+
+func (s *Storage) CreateSigningKey(ctx context.Context, key models.StoredSigningKey) (result bool, err error) {
+	ctx, span := tracer.Start(ctx, "storage.CreateSigningKey")
+	defer func() { endSpan(span, err) }()
+	return s.next.CreateSigningKey(ctx, key)
+}
+
+func (s *Storage) IsSigningKeyTombstoned(ctx context.Context, kid string) (result bool, err error) {
+	ctx, span := tracer.Start(ctx, "storage.IsSigningKeyTombstoned")
+	defer func() { endSpan(span, err) }()
+	return s.next.IsSigningKeyTombstoned(ctx, kid)
+}
+
+func (s *Storage) EncryptSigningKey(ctx context.Context, kid string, sealed []byte) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.EncryptSigningKey")
+	defer func() { endSpan(span, err) }()
+	return s.next.EncryptSigningKey(ctx, kid, sealed)
+}
+
+func (s *Storage) GetSigningKey(ctx context.Context, kid string) (result *models.StoredSigningKey, err error) {
+	ctx, span := tracer.Start(ctx, "storage.GetSigningKey")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetSigningKey(ctx, kid)
+}
+
+func (s *Storage) ListSigningKeys(
+	ctx context.Context,
+	now time.Time,
+) (result []models.StoredSigningKey, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ListSigningKeys")
+	defer func() { endSpan(span, err) }()
+	return s.next.ListSigningKeys(ctx, now)
+}
+
+func (s *Storage) ListDueSigningKeys(
+	ctx context.Context,
+	now time.Time,
+) (result []models.StoredSigningKey, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ListDueSigningKeys")
+	defer func() { endSpan(span, err) }()
+	return s.next.ListDueSigningKeys(ctx, now)
+}
+
+func (s *Storage) DeleteRetiredSigningKeys(ctx context.Context, now time.Time) (result int64, err error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteRetiredSigningKeys")
+	defer func() { endSpan(span, err) }()
+	return s.next.DeleteRetiredSigningKeys(ctx, now)
+}
+
+func (s *Storage) EnqueueWebhookEvent(ctx context.Context, event models.OutboxEvent) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.EnqueueWebhookEvent")
+	defer func() { endSpan(span, err) }()
+	return s.next.EnqueueWebhookEvent(ctx, event)
+}
+
+func (s *Storage) ClaimWebhookEvents(
+	ctx context.Context,
+	now,
+	lockedUntil time.Time,
+	limit int,
+) (result []models.OutboxEvent, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ClaimWebhookEvents")
+	defer func() { endSpan(span, err) }()
+	return s.next.ClaimWebhookEvents(ctx, now, lockedUntil, limit)
+}
+
+func (s *Storage) MarkWebhookDelivered(ctx context.Context, id int64) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.MarkWebhookDelivered")
+	defer func() { endSpan(span, err) }()
+	return s.next.MarkWebhookDelivered(ctx, id)
+}
+
+func (s *Storage) RetryWebhookEvent(
+	ctx context.Context,
+	id int64,
+	nextAttemptAt time.Time,
+	lastError string,
+) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.RetryWebhookEvent")
+	defer func() { endSpan(span, err) }()
+	return s.next.RetryWebhookEvent(ctx, id, nextAttemptAt, lastError)
+}
+
+func (s *Storage) MarkWebhookDead(ctx context.Context, id int64, lastError string) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.MarkWebhookDead")
+	defer func() { endSpan(span, err) }()
+	return s.next.MarkWebhookDead(ctx, id, lastError)
+}
+
+func (s *Storage) ReleaseWebhookEvent(ctx context.Context, id int64) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.ReleaseWebhookEvent")
+	defer func() { endSpan(span, err) }()
+	return s.next.ReleaseWebhookEvent(ctx, id)
+}
+
+func (s *Storage) GetWebhookEndpoint(ctx context.Context, id int64) (result *models.WebhookEndpoint, err error) {
+	ctx, span := tracer.Start(ctx, "storage.GetWebhookEndpoint")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetWebhookEndpoint(ctx, id)
+}
+
+func (s *Storage) ListWebhookEndpoints(ctx context.Context) (result []models.WebhookEndpoint, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ListWebhookEndpoints")
+	defer func() { endSpan(span, err) }()
+	return s.next.ListWebhookEndpoints(ctx)
+}
+
+func (s *Storage) DeleteWebhookEndpoint(ctx context.Context, id int64) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.DeleteWebhookEndpoint")
+	defer func() { endSpan(span, err) }()
+	return s.next.DeleteWebhookEndpoint(ctx, id)
+}
+
+func (s *Storage) CreateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.CreateWebhookDelivery")
+	defer func() { endSpan(span, err) }()
+	return s.next.CreateWebhookDelivery(ctx, delivery)
+}
+
+func (s *Storage) ListWebhookDeliveries(
+	ctx context.Context,
+	endpointID int64,
+	limit int,
+) (result []models.WebhookDelivery, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ListWebhookDeliveries")
+	defer func() { endSpan(span, err) }()
+	return s.next.ListWebhookDeliveries(ctx, endpointID, limit)
+}
+
+func (s *Storage) ListAuditEvents(
+	ctx context.Context,
+	filter models.AuditFilter,
+	limit int,
+) (result []models.AuditEvent, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ListAuditEvents")
+	defer func() { endSpan(span, err) }()
+	return s.next.ListAuditEvents(ctx, filter, limit)
+}
+
+func (s *Storage) GetAuditChainHead(ctx context.Context) (result models.AuditChainHead, err error) {
+	ctx, span := tracer.Start(ctx, "storage.GetAuditChainHead")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetAuditChainHead(ctx)
+}
+
+func (s *Storage) ListAuditChain(
+	ctx context.Context,
+	afterSeq int64,
+	limit int,
+) (result []models.AuditEvent, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ListAuditChain")
+	defer func() { endSpan(span, err) }()
+	return s.next.ListAuditChain(ctx, afterSeq, limit)
+}
+
+func (s *Storage) CreateAPIKey(ctx context.Context, key models.APIKey) (result *models.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "storage.CreateAPIKey")
+	defer func() { endSpan(span, err) }()
+	return s.next.CreateAPIKey(ctx, key)
+}
+
+func (s *Storage) GetAPIKey(ctx context.Context, id int64) (result *models.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "storage.GetAPIKey")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetAPIKey(ctx, id)
+}
+
+func (s *Storage) GetAPIKeyByPrefix(ctx context.Context, prefix string) (result *models.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "storage.GetAPIKeyByPrefix")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetAPIKeyByPrefix(ctx, prefix)
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context) (result []models.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ListAPIKeys")
+	defer func() { endSpan(span, err) }()
+	return s.next.ListAPIKeys(ctx)
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int64, now time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.RevokeAPIKey")
+	defer func() { endSpan(span, err) }()
+	return s.next.RevokeAPIKey(ctx, id, now)
+}
+
+func (s *Storage) ExpireAPIKey(ctx context.Context, id int64, expiresAt, now time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.ExpireAPIKey")
+	defer func() { endSpan(span, err) }()
+	return s.next.ExpireAPIKey(ctx, id, expiresAt, now)
+}
+
+func (s *Storage) TouchAPIKey(ctx context.Context, id int64, now time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.TouchAPIKey")
+	defer func() { endSpan(span, err) }()
+	return s.next.TouchAPIKey(ctx, id, now)
+}
+
+func (s *Storage) IssueTokensTx(
+	ctx context.Context,
+	guid string,
+	session models.RefreshSession,
+	events func(user *models.User) ([]models.OutboxEvent, error),
+	audit models.AuditEvent,
+) (result *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "storage.IssueTokensTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.IssueTokensTx(ctx, guid, session, events, audit)
+}
+
+func (s *Storage) RotateTokensTx(
+	ctx context.Context,
+	oldSelector string,
+	newSession models.RefreshSession,
+	userID int64,
+	events []models.OutboxEvent,
+	audit models.AuditEvent,
+) (result *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "storage.RotateTokensTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.RotateTokensTx(ctx, oldSelector, newSession, userID, events, audit)
+}
+
+func (s *Storage) RevokeSessionsTx(
+	ctx context.Context,
+	target models.RevokeTarget,
+	events func(result models.RevokeResult) ([]models.OutboxEvent, error),
+	audit *models.AuditEvent,
+) (result models.RevokeResult, err error) {
+	ctx, span := tracer.Start(ctx, "storage.RevokeSessionsTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.RevokeSessionsTx(ctx, target, events, audit)
+}
+
+func (s *Storage) LinkAuditEventsTx(ctx context.Context, limit int) (result int, err error) {
+	ctx, span := tracer.Start(ctx, "storage.LinkAuditEventsTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.LinkAuditEventsTx(ctx, limit)
+}
+
+func (s *Storage) PromoteSigningKeyTx(ctx context.Context, kid string, now, retireAt time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.PromoteSigningKeyTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.PromoteSigningKeyTx(ctx, kid, now, retireAt)
+}
+
+func (s *Storage) ScheduleSigningKeyTx(
+	ctx context.Context,
+	key models.StoredSigningKey,
+	rotateBefore time.Time,
+) (result bool, err error) {
+	ctx, span := tracer.Start(ctx, "storage.ScheduleSigningKeyTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.ScheduleSigningKeyTx(ctx, key, rotateBefore)
+}
+
+func (s *Storage) SyncWebhookEndpointTx(
+	ctx context.Context,
+	url,
+	secret,
+	format string,
+	eventTypes []string,
+) (created bool, adopted int64, err error) {
+	ctx, span := tracer.Start(ctx, "storage.SyncWebhookEndpointTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.SyncWebhookEndpointTx(ctx, url, secret, format, eventTypes)
+}
+
+func (s *Storage) CreateWebhookEndpointTx(
+	ctx context.Context,
+	endpoint models.WebhookEndpoint,
+) (result *models.WebhookEndpoint, err error) {
+	ctx, span := tracer.Start(ctx, "storage.CreateWebhookEndpointTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.CreateWebhookEndpointTx(ctx, endpoint)
+}
+
+func (s *Storage) UpdateWebhookEndpointTx(
+	ctx context.Context,
+	id int64,
+	update models.WebhookEndpointUpdate,
+) (result *models.WebhookEndpoint, err error) {
+	ctx, span := tracer.Start(ctx, "storage.UpdateWebhookEndpointTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.UpdateWebhookEndpointTx(ctx, id, update)
+}
+
+func (s *Storage) RotateAPIKeyTx(
+	ctx context.Context,
+	oldID int64,
+	newKey models.APIKey,
+	oldExpiresAt time.Time,
+) (result *models.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "storage.RotateAPIKeyTx")
+	defer func() { endSpan(span, err) }()
+	return s.next.RotateAPIKeyTx(ctx, oldID, newKey, oldExpiresAt)
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+// TokenStorage спаны вокруг denylist и результатов ротации. Отдельные команды Redis
+// видны внутри них через redisotel.
+type TokenStorage struct {
+	next storage.TokenStorage
+}
+
+var _ storage.TokenStorage = (*TokenStorage)(nil)
+
+func NewTokenStorage(next storage.TokenStorage) *TokenStorage {
+	return &TokenStorage{next: next}
+}
+
+func (s *TokenStorage) InvalidateToken(ctx context.Context, jti string, expiration time.Duration) (err error) {
+	ctx, span := tracer.Start(ctx, "tokenstorage.InvalidateToken")
+	defer func() { endSpan(span, err) }()
+	return s.next.InvalidateToken(ctx, jti, expiration)
+}
+
+func (s *TokenStorage) IsTokenInvalidated(ctx context.Context, jti string) (result bool, err error) {
+	ctx, span := tracer.Start(ctx, "tokenstorage.IsTokenInvalidated")
+	defer func() { endSpan(span, err) }()
+	return s.next.IsTokenInvalidated(ctx, jti)
+}
+
+func (s *TokenStorage) SaveRotationResult(
+	ctx context.Context,
+	selector string,
+	sealed []byte,
+	ttl time.Duration,
+) (err error) {
+	ctx, span := tracer.Start(ctx, "tokenstorage.SaveRotationResult")
+	defer func() { endSpan(span, err) }()
+	return s.next.SaveRotationResult(ctx, selector, sealed, ttl)
+}
+
+func (s *TokenStorage) GetRotationResult(ctx context.Context, selector string) (result []byte, err error) {
+	ctx, span := tracer.Start(ctx, "tokenstorage.GetRotationResult")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetRotationResult(ctx, selector)
+}
@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rryowa/medods_dvortsov/internal/storage"
+)
+
+//nolint:gochecknoglobals // tracer берет провайдер из otel при каждом Start, поэтому может быть создан до его настройки
+var tracer = otel.Tracer("github.com/rryowa/medods_dvortsov/internal/storage")
+
+// endSpan закрывает спан вызова. "Не найдено" - штатный ответ хранилища, а не сбой:
+// такой спан не помечается ошибкой, чтобы не зашумлять поиск по ошибкам
+func endSpan(span trace.Span, err error) {
+	if err != nil && !isNotFound(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, storage.ErrSessionNotFound) ||
+		errors.Is(err, storage.ErrUserNotFound) ||
+		errors.Is(err, storage.ErrKeyNotFound) ||
+		errors.Is(err, storage.ErrAPIKeyNotFound) ||
+		errors.Is(err, storage.ErrWebhookEndpointNotFound)
+}
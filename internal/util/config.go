@@ -1,13 +1,20 @@
 package util
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"log"
-	"os"
-	"strconv"
+	"net/netip"
+	"net/url"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
 )
 
 //nolint:gochecknoglobals // here its ok
@@ -23,101 +30,423 @@ func init() {
 
 const (
 	defaultServerAddr      = "localhost:8080"
+	defaultAdminAddr       = "localhost:9091"
 	defaultWriteTimeout    = 10 * time.Second
 	defaultReadTimeout     = 10 * time.Second
 	defaultIdleTimeout     = 30 * time.Second
 	defaultGracefulTimeout = 5 * time.Second
+	defaultShutdownDrain   = 5 * time.Second
 
 	defaultAccessTTL  = 15 * time.Minute
 	defaultRefreshTTL = 24 * time.Hour
+	defaultSigningAlg = "HS512"
+	// keyEncryptionKeySize размер ключа AES-256
+	keyEncryptionKeySize = 32
+
+	defaultKeyOverlap        = 30 * time.Minute
+	defaultKeyPublishDelay   = 10 * time.Minute
+	defaultKeyReloadInterval = 30 * time.Second
+
+	defaultAPIKeyRotationOverlap = 24 * time.Hour
+
+	defaultReuseRevokeScope = models.RevokeScopeFamily
+	defaultReuseGraceWindow = 10 * time.Second
+
+	defaultCleanupInterval  = 10 * time.Minute
+	defaultCleanupRetention = 24 * time.Hour
+	defaultCleanupBatchSize = 1000
+
+	defaultWebhookFormat       = models.WebhookFormatJSON
+	defaultWebhookEventSource  = "/auth-service"
+	defaultWebhookWorkers      = 4
+	defaultWebhookBatchSize    = 10
+	defaultWebhookMaxAttempts  = 10
+	defaultWebhookTimeout      = 10 * time.Second
+	defaultWebhookPollInterval = 1 * time.Second
+	defaultWebhookBackoffBase  = 5 * time.Second
+	defaultWebhookBackoffMax   = 1 * time.Hour
 
 	defaultRateLimit     = 100
 	defaultRateInterval  = 1 * time.Minute
 	defaultRateBlockTime = 5 * time.Minute
 
+	defaultLogLevel = "debug"
+
+	defaultServiceName = "auth-service"
+	defaultSampleRatio = 1.0
+
 	TokenPartsExpected = 2
 	RawTokenLength     = 32
 	JWTLeeWay          = 5 * time.Second
 )
 
-type ServerConfig struct {
-	ServerAddr      string
-	WriteTimeout    time.Duration
-	ReadTimeout     time.Duration
-	IdleTimeout     time.Duration
-	GracefulTimeout time.Duration
+// Config конфигурация сервиса целиком. Источники по возрастанию приоритета:
+// значения по умолчанию, YAML-файл, переменные окружения (тег env).
+// Поля с тегом secret можно передать файлом через <VAR>_FILE и они скрываются в Redacted.
+type Config struct {
+	Log       LogConfig         `yaml:"log"`
+	Server    ServerConfig      `yaml:"server"`
+	DB        DBConfig          `yaml:"database"`
+	Redis     RedisConfig       `yaml:"redis"`
+	Token     TokenConfig       `yaml:"token"`
+	APIKey    APIKeyConfig      `yaml:"api_key"`
+	RateLimit RateLimiterConfig `yaml:"rate_limit"`
+	Session   SessionConfig     `yaml:"session"`
+	Webhook   WebhookConfig     `yaml:"webhook"`
+	Tracing   TracingConfig     `yaml:"tracing"`
 }
 
-func NewServerConfig() *ServerConfig {
-	addr := os.Getenv("SERVER_ADDRESS")
-	if addr == "" {
-		addr = defaultServerAddr
-	}
+type LogConfig struct {
+	// Level debug, info, warn или error. Меняется без перезапуска (SIGHUP)
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+}
 
-	return &ServerConfig{
-		ServerAddr:      addr,
-		WriteTimeout:    parseDurationOrDefault("WRITE_TIMEOUT", defaultWriteTimeout),
-		ReadTimeout:     parseDurationOrDefault("READ_TIMEOUT", defaultReadTimeout),
-		IdleTimeout:     parseDurationOrDefault("IDLE_TIMEOUT", defaultIdleTimeout),
-		GracefulTimeout: parseDurationOrDefault("GRACEFUL_TIMEOUT", defaultGracefulTimeout),
-	}
+type ServerConfig struct {
+	ServerAddr string `yaml:"address" env:"SERVER_ADDRESS"`
+	// AdminAddr адрес служебного сервера (/metrics), не должен быть доступен снаружи
+	AdminAddr       string        `yaml:"admin_address" env:"ADMIN_ADDRESS"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"READ_TIMEOUT"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT"`
+	GracefulTimeout time.Duration `yaml:"graceful_timeout" env:"GRACEFUL_TIMEOUT"`
+	// ShutdownDrain сколько после снятия готовности сервер еще принимает новые запросы,
+	// пока балансировщик не увидит упавший /readyz. 0 - останавливаться сразу
+	ShutdownDrain time.Duration `yaml:"shutdown_drain" env:"SHUTDOWN_DRAIN"`
+	// TrustedProxies адреса и CIDR прокси, чьему X-Forwarded-For верить. Пусто - IP клиента
+	// берется из адреса соединения, заголовки игнорируются. Применяется только при старте
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+// TrustedProxyPrefixes разбирает TrustedProxies так же, как RateLimiterConfig.AllowlistPrefixes
+func (c *ServerConfig) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	return parsePrefixes(c.TrustedProxies)
 }
 
 type TokenConfig struct {
-	JwtSecretKey []byte
-	AccessTTL    time.Duration
-	RefreshTTL   time.Duration
+	// SigningAlg HS512 (общий секрет JWT_SECRET) или RS256/ES256/EdDSA (приватный ключ)
+	SigningAlg string `yaml:"signing_alg" env:"JWT_SIGNING_ALG"`
+	JWTSecret  string `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	// PrivateKey PEM приватного ключа, обычно передается файлом: JWT_PRIVATE_KEY_FILE
+	PrivateKey string `yaml:"private_key" env:"JWT_PRIVATE_KEY" secret:"true"`
+	// KeyID значение заголовка kid, по умолчанию вычисляется из ключа
+	KeyID string `yaml:"key_id" env:"JWT_KEY_ID"`
+	// KeyEncryptionKey base64 ключа AES-256, которым шифруются ключи подписи в БД (make keygen)
+	KeyEncryptionKey string        `yaml:"key_encryption_key" env:"JWT_KEY_ENCRYPTION_KEY" secret:"true"`
+	AccessTTL        time.Duration `yaml:"access_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTTL       time.Duration `yaml:"refresh_ttl" env:"REFRESH_TOKEN_TTL"`
+
+	// KeyOverlap сколько выведенный из ротации ключ продолжает проверять токены
+	KeyOverlap time.Duration `yaml:"key_overlap" env:"JWT_KEY_OVERLAP"`
+	// KeyRotationInterval период автоматической ротации ключа (0 - выключена)
+	KeyRotationInterval time.Duration `yaml:"key_rotation_interval" env:"JWT_KEY_ROTATION_INTERVAL"`
+	// KeyPublishDelay сколько новый ключ висит в JWKS до того, как начнет подписывать
+	KeyPublishDelay time.Duration `yaml:"key_publish_delay" env:"JWT_KEY_PUBLISH_DELAY"`
+	// KeyReloadInterval как часто реплика перечитывает key ring из БД
+	KeyReloadInterval time.Duration `yaml:"key_reload_interval" env:"JWT_KEY_RELOAD_INTERVAL"`
 }
 
-func NewTokenConfig() *TokenConfig {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Fatal("JWT_SECRET is not set")
+// EncryptionKey декодирует KeyEncryptionKey: base64 ровно keyEncryptionKeySize байт
+func (c *TokenConfig) EncryptionKey() ([]byte, error) {
+	if c.KeyEncryptionKey == "" {
+		return nil, errors.New("is required")
 	}
-	return &TokenConfig{
-		JwtSecretKey: []byte(secret),
-		AccessTTL:    parseDurationOrDefault("ACCESS_TOKEN_TTL", defaultAccessTTL),
-		RefreshTTL:   parseDurationOrDefault("REFRESH_TOKEN_TTL", defaultRefreshTTL),
+	key, err := base64.StdEncoding.DecodeString(c.KeyEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
 	}
+	if len(key) != keyEncryptionKeySize {
+		return nil, fmt.Errorf("must be %d bytes, got %d", keyEncryptionKeySize, len(key))
+	}
+	return key, nil
 }
 
+// APIKeyConfig ключ, которым сервисы-клиенты подписывают запросы (X-API-Key)
+type APIKeyConfig struct {
+	Key string `yaml:"key" env:"AUTH_SERVICE_API_KEY" secret:"true"`
+	// RotationOverlap сколько прежний ключ принимается после ротации: для общего ключа
+	// после смены AUTH_SERVICE_API_KEY, для именованного - если в запросе ротации срок не указан
+	RotationOverlap time.Duration `yaml:"rotation_overlap" env:"API_KEY_ROTATION_OVERLAP"`
+}
+
+// RateLimiterConfig Limit, Interval и BlockTime - лимит по IP для операций без своей политики
 type RateLimiterConfig struct {
-	Limit     int
-	Interval  time.Duration
-	BlockTime time.Duration
-}
-
-func NewRateLimiterConfig() *RateLimiterConfig {
-	limitStr := os.Getenv("RATE_LIMIT_LIMIT")
-	limit := defaultRateLimit
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
-		} else {
-			log.Printf("Invalid RATE_LIMIT_LIMIT: %s, using default %d", limitStr, defaultRateLimit)
+	Limit     int           `yaml:"limit" env:"RATE_LIMIT_LIMIT"`
+	Interval  time.Duration `yaml:"interval" env:"RATE_LIMIT_INTERVAL"`
+	BlockTime time.Duration `yaml:"block_time" env:"RATE_LIMIT_BLOCK_TIME"`
+	// Allowlist адреса и CIDR, запросы с которых не ограничиваются (внутренние сервисы, мониторинг)
+	Allowlist []string `yaml:"allowlist" env:"RATE_LIMIT_ALLOWLIST"`
+	// Policies лимиты отдельных операций OpenAPI. Задаются только в файле
+	Policies []RateLimitPolicy `yaml:"policies"`
+}
+
+// RateLimitPolicy лимит для операций Operations (operationId), который считается отдельно
+// для каждого значения Key. Операция может входить в несколько политик, тогда действуют все.
+type RateLimitPolicy struct {
+	// Name часть ключа счетчика в Redis: по нему политики не пересекаются
+	Name       string        `yaml:"name"`
+	Operations []string      `yaml:"operations"`
+	Key        string        `yaml:"key"`
+	Limit      int           `yaml:"limit"`
+	Interval   time.Duration `yaml:"interval"`
+	BlockTime  time.Duration `yaml:"block_time"`
+}
+
+// AllowlistPrefixes разбирает Allowlist: отдельный адрес - сеть из одного адреса
+func (c *RateLimiterConfig) AllowlistPrefixes() ([]netip.Prefix, error) {
+	return parsePrefixes(c.Allowlist)
+}
+
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address or CIDR %q", entry)
 		}
+		prefixes = append(prefixes, prefix.Masked())
 	}
+	return prefixes, nil
+}
 
-	interval := parseDurationOrDefault("RATE_LIMIT_INTERVAL", defaultRateInterval)
-	blockTime := parseDurationOrDefault("RATE_LIMIT_BLOCK_TIME", defaultRateBlockTime)
+type SessionConfig struct {
+	// ReuseRevokeScope что отзывать при повторном использовании refresh-токена:
+	// "family" - только цепочку ротаций этого логина, "user" - все сессии пользователя
+	ReuseRevokeScope string `yaml:"reuse_revoke_scope" env:"REFRESH_REUSE_REVOKE_SCOPE"`
+	// ReuseGraceWindow сколько после ротации повтор старого refresh-токена возвращает
+	// уже выданную пару, а не считается кражей (ретрай клиента после таймаута). 0 - выключено
+	ReuseGraceWindow time.Duration `yaml:"reuse_grace_window" env:"REFRESH_REUSE_GRACE_WINDOW"`
+	// CleanupInterval период фоновой очистки сессий. 0 - очистка выключена
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env:"SESSION_CLEANUP_INTERVAL"`
+	// CleanupRetention сколько хранить истекшие и использованные сессии.
+	// Пока использованная сессия хранится, ее повторное использование распознается как кража
+	CleanupRetention time.Duration `yaml:"cleanup_retention" env:"SESSION_CLEANUP_RETENTION"`
+	// CleanupBatchSize сколько строк удалять за один DELETE, чтобы не держать долгие блокировки
+	CleanupBatchSize int `yaml:"cleanup_batch_size" env:"SESSION_CLEANUP_BATCH_SIZE"`
+}
+
+// WebhookConfig настройки доставки. URL, Secret и Events задают endpoint,
+// который создается при старте; остальные endpoint'ы хранятся в БД.
+type WebhookConfig struct {
+	URL string `yaml:"url" env:"WEBHOOK_URL"`
+	// Secret ключ HMAC-подписи доставок, общий с получателем
+	Secret string `yaml:"secret" env:"WEBHOOK_SECRET" secret:"true"`
+	// Events типы событий, на которые подписывается endpoint из WEBHOOK_URL, "*" - все
+	Events []string `yaml:"events" env:"WEBHOOK_EVENTS"`
+	// Format формат доставки на WEBHOOK_URL: json, cloudevents-structured или cloudevents-binary
+	Format string `yaml:"format" env:"WEBHOOK_FORMAT"`
+	// EventSource атрибут source событий CloudEvents, из него же выводятся детерминированные ID событий
+	EventSource string `yaml:"event_source" env:"WEBHOOK_EVENT_SOURCE"`
+	// Workers число параллельных воркеров доставки
+	Workers int `yaml:"workers" env:"WEBHOOK_WORKERS"`
+	// BatchSize сколько событий воркер забирает из outbox за раз
+	BatchSize int `yaml:"batch_size" env:"WEBHOOK_BATCH_SIZE"`
+	// MaxAttempts после стольких неудачных попыток событие переходит в dead
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL"`
+	// BackoffBase и BackoffMax задают экспоненциальную задержку между попытками
+	BackoffBase time.Duration `yaml:"backoff_base" env:"WEBHOOK_BACKOFF_BASE"`
+	BackoffMax  time.Duration `yaml:"backoff_max" env:"WEBHOOK_BACKOFF_MAX"`
+	// AllowedNetworks адреса и CIDR, на которые можно доставлять endpoint'ам из API,
+	// хотя они закрыты по умолчанию: частные сети, loopback. Меняется без перезапуска (SIGHUP)
+	AllowedNetworks []string `yaml:"allowed_networks" env:"WEBHOOK_ALLOWED_NETWORKS"`
+}
 
-	return &RateLimiterConfig{
-		Limit:     limit,
-		Interval:  interval,
-		BlockTime: blockTime,
+// AllowedNetworkPrefixes разбирает AllowedNetworks так же, как RateLimiterConfig.AllowlistPrefixes
+func (c *WebhookConfig) AllowedNetworkPrefixes() ([]netip.Prefix, error) {
+	return parsePrefixes(c.AllowedNetworks)
+}
+
+// DefaultConfig значения, которые действуют, если их не задали ни файл, ни окружение
+func DefaultConfig() Config {
+	return Config{
+		Log: LogConfig{
+			Level: defaultLogLevel,
+		},
+		Server: ServerConfig{
+			ServerAddr:      defaultServerAddr,
+			AdminAddr:       defaultAdminAddr,
+			WriteTimeout:    defaultWriteTimeout,
+			ReadTimeout:     defaultReadTimeout,
+			IdleTimeout:     defaultIdleTimeout,
+			GracefulTimeout: defaultGracefulTimeout,
+			ShutdownDrain:   defaultShutdownDrain,
+		},
+		DB: DBConfig{
+			AutoMigrate: true,
+		},
+		Token: TokenConfig{
+			SigningAlg:        defaultSigningAlg,
+			AccessTTL:         defaultAccessTTL,
+			RefreshTTL:        defaultRefreshTTL,
+			KeyOverlap:        defaultKeyOverlap,
+			KeyPublishDelay:   defaultKeyPublishDelay,
+			KeyReloadInterval: defaultKeyReloadInterval,
+		},
+		APIKey: APIKeyConfig{
+			RotationOverlap: defaultAPIKeyRotationOverlap,
+		},
+		RateLimit: RateLimiterConfig{
+			Limit:     defaultRateLimit,
+			Interval:  defaultRateInterval,
+			BlockTime: defaultRateBlockTime,
+		},
+		Session: SessionConfig{
+			ReuseRevokeScope: defaultReuseRevokeScope,
+			ReuseGraceWindow: defaultReuseGraceWindow,
+			CleanupInterval:  defaultCleanupInterval,
+			CleanupRetention: defaultCleanupRetention,
+			CleanupBatchSize: defaultCleanupBatchSize,
+		},
+		Webhook: WebhookConfig{
+			Events:       []string{models.WebhookEventAll},
+			Format:       defaultWebhookFormat,
+			EventSource:  defaultWebhookEventSource,
+			Workers:      defaultWebhookWorkers,
+			BatchSize:    defaultWebhookBatchSize,
+			MaxAttempts:  defaultWebhookMaxAttempts,
+			Timeout:      defaultWebhookTimeout,
+			PollInterval: defaultWebhookPollInterval,
+			BackoffBase:  defaultWebhookBackoffBase,
+			BackoffMax:   defaultWebhookBackoffMax,
+		},
+		Tracing: TracingConfig{
+			ServiceName: defaultServiceName,
+			SampleRatio: defaultSampleRatio,
+		},
 	}
 }
 
-func GetWebhookURL() string {
-	return os.Getenv("WEBHOOK_URL")
+// ValidateDB проверяет только то, что нужно для работы с БД без запуска сервиса
+func (c *Config) ValidateDB() error {
+	var errs []error
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level (LOG_LEVEL): %w", err))
+	}
+	if c.DB.DSN == "" {
+		errs = append(errs, errors.New("database.dsn (DATABASE_URL) is required"))
+	}
+	return errors.Join(errs...)
 }
 
-func parseDurationOrDefault(varName string, def time.Duration) time.Duration {
-	if v := os.Getenv(varName); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			return d
+// Validate проверяет конфигурацию целиком и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
 		}
-		log.Printf("Invalid duration in %s: %s, using default %s", varName, v, def)
 	}
-	return def
+	positive := func(d time.Duration, name string) {
+		check(d > 0, "%s must be positive, got %s", name, d)
+	}
+	nonNegative := func(d time.Duration, name string) {
+		check(d >= 0, "%s must not be negative, got %s", name, d)
+	}
+
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level (LOG_LEVEL): %w", err))
+	}
+
+	check(c.Server.ServerAddr != "", "server.address (SERVER_ADDRESS) is required")
+	check(c.Server.AdminAddr != "", "server.admin_address (ADMIN_ADDRESS) is required")
+	positive(c.Server.WriteTimeout, "server.write_timeout (WRITE_TIMEOUT)")
+	positive(c.Server.ReadTimeout, "server.read_timeout (READ_TIMEOUT)")
+	positive(c.Server.IdleTimeout, "server.idle_timeout (IDLE_TIMEOUT)")
+	positive(c.Server.GracefulTimeout, "server.graceful_timeout (GRACEFUL_TIMEOUT)")
+	nonNegative(c.Server.ShutdownDrain, "server.shutdown_drain (SHUTDOWN_DRAIN)")
+	if _, err := c.Server.TrustedProxyPrefixes(); err != nil {
+		check(false, "server.trusted_proxies (TRUSTED_PROXIES): %v", err)
+	}
+
+	check(c.DB.DSN != "", "database.dsn (DATABASE_URL) is required")
+	check(c.Redis.Addr != "", "redis.address (REDIS_ADDR) is required")
+
+	switch c.Token.SigningAlg {
+	case defaultSigningAlg:
+		check(c.Token.JWTSecret != "", "token.jwt_secret (JWT_SECRET) is required for %s", c.Token.SigningAlg)
+	case "RS256", "ES256", "EdDSA":
+		check(c.Token.PrivateKey != "",
+			"token.private_key (JWT_PRIVATE_KEY or JWT_PRIVATE_KEY_FILE) is required for %s", c.Token.SigningAlg)
+	default:
+		errs = append(errs, fmt.Errorf("token.signing_alg (JWT_SIGNING_ALG): unsupported algorithm %q", c.Token.SigningAlg))
+	}
+	if _, err := c.Token.EncryptionKey(); err != nil {
+		check(false, "token.key_encryption_key (JWT_KEY_ENCRYPTION_KEY): %v", err)
+	}
+	positive(c.Token.AccessTTL, "token.access_ttl (ACCESS_TOKEN_TTL)")
+	positive(c.Token.RefreshTTL, "token.refresh_ttl (REFRESH_TOKEN_TTL)")
+	nonNegative(c.Token.KeyOverlap, "token.key_overlap (JWT_KEY_OVERLAP)")
+	nonNegative(c.Token.KeyRotationInterval, "token.key_rotation_interval (JWT_KEY_ROTATION_INTERVAL)")
+	nonNegative(c.Token.KeyPublishDelay, "token.key_publish_delay (JWT_KEY_PUBLISH_DELAY)")
+	positive(c.Token.KeyReloadInterval, "token.key_reload_interval (JWT_KEY_RELOAD_INTERVAL)")
+
+	check(c.APIKey.Key != "", "api_key.key (AUTH_SERVICE_API_KEY) is required")
+	nonNegative(c.APIKey.RotationOverlap, "api_key.rotation_overlap (API_KEY_ROTATION_OVERLAP)")
+
+	check(c.RateLimit.Limit > 0, "rate_limit.limit (RATE_LIMIT_LIMIT) must be positive, got %d", c.RateLimit.Limit)
+	positive(c.RateLimit.Interval, "rate_limit.interval (RATE_LIMIT_INTERVAL)")
+	nonNegative(c.RateLimit.BlockTime, "rate_limit.block_time (RATE_LIMIT_BLOCK_TIME)")
+	if _, err := c.RateLimit.AllowlistPrefixes(); err != nil {
+		check(false, "rate_limit.allowlist (RATE_LIMIT_ALLOWLIST): %v", err)
+	}
+	policyNames := make(map[string]bool, len(c.RateLimit.Policies))
+	for i, p := range c.RateLimit.Policies {
+		name := fmt.Sprintf("rate_limit.policies[%d]", i)
+		check(p.Name != "", "%s.name is required", name)
+		check(!policyNames[p.Name], "%s.name %q is not unique", name, p.Name)
+		policyNames[p.Name] = true
+		check(len(p.Operations) > 0, "%s.operations must not be empty", name)
+		check(slices.Contains(models.RateLimitKeys(), p.Key),
+			"%s.key must be one of %v, got %q", name, models.RateLimitKeys(), p.Key)
+		check(p.Key != models.RateLimitKeyUser || !slices.Contains(p.Operations, models.OperationRefreshTokens),
+			"%s.key %q never applies to %s: refresh is not authenticated with an access token",
+			name, p.Key, models.OperationRefreshTokens)
+		check(p.Limit > 0, "%s.limit must be positive, got %d", name, p.Limit)
+		positive(p.Interval, name+".interval")
+		nonNegative(p.BlockTime, name+".block_time")
+	}
+
+	check(slices.Contains([]string{models.RevokeScopeFamily, models.RevokeScopeUser}, c.Session.ReuseRevokeScope),
+		"session.reuse_revoke_scope (REFRESH_REUSE_REVOKE_SCOPE) must be %q or %q, got %q",
+		models.RevokeScopeFamily, models.RevokeScopeUser, c.Session.ReuseRevokeScope)
+	nonNegative(c.Session.ReuseGraceWindow, "session.reuse_grace_window (REFRESH_REUSE_GRACE_WINDOW)")
+	nonNegative(c.Session.CleanupInterval, "session.cleanup_interval (SESSION_CLEANUP_INTERVAL)")
+	positive(c.Session.CleanupRetention, "session.cleanup_retention (SESSION_CLEANUP_RETENTION)")
+	check(c.Session.CleanupBatchSize > 0,
+		"session.cleanup_batch_size (SESSION_CLEANUP_BATCH_SIZE) must be positive, got %d", c.Session.CleanupBatchSize)
+
+	if c.Webhook.URL != "" {
+		u, err := url.Parse(c.Webhook.URL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"webhook.url (WEBHOOK_URL) must be an absolute http(s) URL")
+		check(c.Webhook.Secret != "", "webhook.secret (WEBHOOK_SECRET) is required when webhook.url is set")
+		check(len(c.Webhook.Events) > 0, "webhook.events (WEBHOOK_EVENTS) must not be empty")
+	}
+	check(slices.Contains([]string{
+		models.WebhookFormatJSON, models.WebhookFormatCloudEventsStructured, models.WebhookFormatCloudEventsBinary,
+	}, c.Webhook.Format), "webhook.format (WEBHOOK_FORMAT): unsupported format %q", c.Webhook.Format)
+	check(c.Webhook.EventSource != "", "webhook.event_source (WEBHOOK_EVENT_SOURCE) is required")
+	check(c.Webhook.Workers > 0, "webhook.workers (WEBHOOK_WORKERS) must be positive, got %d", c.Webhook.Workers)
+	check(c.Webhook.BatchSize > 0, "webhook.batch_size (WEBHOOK_BATCH_SIZE) must be positive, got %d", c.Webhook.BatchSize)
+	check(c.Webhook.MaxAttempts > 0,
+		"webhook.max_attempts (WEBHOOK_MAX_ATTEMPTS) must be positive, got %d", c.Webhook.MaxAttempts)
+	positive(c.Webhook.Timeout, "webhook.timeout (WEBHOOK_TIMEOUT)")
+	positive(c.Webhook.PollInterval, "webhook.poll_interval (WEBHOOK_POLL_INTERVAL)")
+	positive(c.Webhook.BackoffBase, "webhook.backoff_base (WEBHOOK_BACKOFF_BASE)")
+	check(c.Webhook.BackoffMax >= c.Webhook.BackoffBase,
+		"webhook.backoff_max (WEBHOOK_BACKOFF_MAX) must not be less than webhook.backoff_base")
+	if _, err := c.Webhook.AllowedNetworkPrefixes(); err != nil {
+		check(false, "webhook.allowed_networks (WEBHOOK_ALLOWED_NETWORKS): %v", err)
+	}
+
+	check(c.Tracing.ServiceName != "", "tracing.service_name (OTEL_SERVICE_NAME) is required")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio (OTEL_TRACES_SAMPLER_ARG) must be within [0, 1], got %v", c.Tracing.SampleRatio)
+
+	return errors.Join(errs...)
 }
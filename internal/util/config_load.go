@@ -0,0 +1,246 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigFileEnv путь к YAML-файлу конфигурации, если он не передан флагом
+	ConfigFileEnv = "CONFIG_FILE"
+
+	// fileEnvSuffix <VAR>_FILE: значение секрета читается из файла (docker secrets)
+	fileEnvSuffix = "_FILE"
+	redacted      = "[REDACTED]"
+)
+
+// LoadConfig собирает конфигурацию: значения по умолчанию, затем файл path (или CONFIG_FILE),
+// затем переменные окружения. Ошибки разбора и валидации возвращаются все вместе,
+// неверное значение не подменяется значением по умолчанию.
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	if path != "" {
+		if err := loadConfigFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	errs := applyEnv(reflect.ValueOf(&cfg).Elem())
+	if len(errs) == 0 {
+		if err := cfg.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+	return &cfg, nil
+}
+
+// LoadDBConfig загружает конфигурацию так же, как LoadConfig, но окружение читает и проверяет
+// только секции database и log. Для команд, которым нужна лишь БД (migrate): шагу деплоя с миграциями
+// не нужны ключи подписи, Redis и прочие секреты сервиса.
+func LoadDBConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	if path != "" {
+		if err := loadConfigFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	errs := applyEnv(reflect.ValueOf(&cfg.DB).Elem())
+	errs = append(errs, applyEnv(reflect.ValueOf(&cfg.Log).Elem())...)
+	if len(errs) == 0 {
+		if err := cfg.ValidateDB(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+	return &cfg, nil
+}
+
+func loadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	// Неизвестный ключ в файле - почти всегда опечатка, которая иначе тихо вернет значение по умолчанию
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv перекрывает поля с тегом env значениями из окружения. Пустая переменная считается незаданной.
+func applyEnv(v reflect.Value) []error {
+	var errs []error
+	t := v.Type()
+	for i := range t.NumField() {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			errs = append(errs, applyEnv(value)...)
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw, ok, err := lookupEnv(name, field.Tag.Get("secret") != "")
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := setFromString(value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+// lookupEnv для секретов сначала смотрит <name>_FILE. Задать и значение, и файл - ошибка:
+// непонятно, какое из них должно победить.
+func lookupEnv(name string, secret bool) (string, bool, error) {
+	value := os.Getenv(name)
+	if !secret {
+		return value, value != "", nil
+	}
+
+	file := os.Getenv(name + fileEnvSuffix)
+	if file == "" {
+		return value, value != "", nil
+	}
+	if value != "" {
+		return "", false, fmt.Errorf("%s and %s%s are both set", name, name, fileEnvSuffix)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", false, fmt.Errorf("%s%s: %w", name, fileEnvSuffix, err)
+	}
+	// Файлы секретов обычно заканчиваются переводом строки, в PEM он не мешает, в секрете - да
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+//nolint:exhaustive // в конфигурации используются только эти типы
+func setFromString(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+		return nil
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+		return nil
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+		return nil
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+		return nil
+	default:
+		return fmt.Errorf("unsupported config field type %s", v.Type())
+	}
+}
+
+// Redacted копия конфигурации, безопасная для вывода: секреты заменены, у DSN скрыт пароль
+func (c *Config) Redacted() Config {
+	cp := *c
+	redactSecrets(reflect.ValueOf(&cp).Elem())
+	return cp
+}
+
+// DumpRedacted эффективная конфигурация в YAML со скрытыми секретами
+func (c *Config) DumpRedacted(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return nil
+}
+
+func redactSecrets(v reflect.Value) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			redactSecrets(value)
+			continue
+		}
+		if value.Kind() != reflect.String || value.String() == "" {
+			continue
+		}
+		switch field.Tag.Get("secret") {
+		case "true":
+			value.SetString(redacted)
+		case "url":
+			value.SetString(redactURL(value.String()))
+		}
+	}
+}
+
+// redactURL скрывает только пароль: хост и база в выводе нужны, чтобы проверить, куда сервис подключается.
+// DSN не в виде URL (key=value) скрывается целиком.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return redacted
+	}
+	return u.Redacted()
+}
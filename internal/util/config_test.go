@@ -0,0 +1,249 @@
+package util_test
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rryowa/medods_dvortsov/internal/models"
+	"github.com/rryowa/medods_dvortsov/internal/util"
+)
+
+func TestRateLimiterConfigAllowlistPrefixes(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist []string
+		want      []string
+		wantErr   bool
+	}{
+		{name: "empty", want: []string{}},
+		{name: "ipv4 address", allowlist: []string{"192.0.2.1"}, want: []string{"192.0.2.1/32"}},
+		{name: "ipv6 address", allowlist: []string{"2001:db8::1"}, want: []string{"2001:db8::1/128"}},
+		{name: "ipv4-mapped address", allowlist: []string{"::ffff:192.0.2.1"}, want: []string{"192.0.2.1/32"}},
+		{name: "cidr is masked", allowlist: []string{"10.1.2.3/8"}, want: []string{"10.0.0.0/8"}},
+		{
+			name:      "mixed",
+			allowlist: []string{"127.0.0.1", "2001:db8::/32"},
+			want:      []string{"127.0.0.1/32", "2001:db8::/32"},
+		},
+		{name: "hostname", allowlist: []string{"localhost"}, wantErr: true},
+		{name: "bad prefix length", allowlist: []string{"10.0.0.0/33"}, wantErr: true},
+		{name: "one bad entry", allowlist: []string{"127.0.0.1", "10.0.0.0/"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := util.RateLimiterConfig{Allowlist: tt.allowlist}
+			prefixes, err := cfg.AllowlistPrefixes()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AllowlistPrefixes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := make([]string, 0, len(prefixes))
+			for _, p := range prefixes {
+				got = append(got, p.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("AllowlistPrefixes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	cfg := util.RateLimiterConfig{Allowlist: []string{"::ffff:192.0.2.1"}}
+	prefixes, err := cfg.AllowlistPrefixes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prefixes[0].Contains(netip.MustParseAddr("192.0.2.1")) {
+		t.Errorf("prefix %s does not contain the unmapped address", prefixes[0])
+	}
+}
+
+// validConfig конфигурация по умолчанию с обязательными секретами
+func validConfig() util.Config {
+	cfg := util.DefaultConfig()
+	cfg.DB.DSN = "postgres://localhost/db"
+	cfg.Redis.Addr = "localhost:6379"
+	cfg.Token.JWTSecret = "secret"
+	cfg.Token.KeyEncryptionKey = "MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="
+	cfg.APIKey.Key = "api-key"
+	return cfg
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *util.Config)
+		wantErr []string
+	}{
+		{name: "defaults with secrets", modify: func(*util.Config) {}},
+		{name: "log level", modify: func(c *util.Config) { c.Log.Level = "verbose" }, wantErr: []string{"log.level"}},
+		{name: "dsn", modify: func(c *util.Config) { c.DB.DSN = "" }, wantErr: []string{"database.dsn"}},
+		{
+			name:    "zero timeout",
+			modify:  func(c *util.Config) { c.Server.ReadTimeout = 0 },
+			wantErr: []string{"server.read_timeout (READ_TIMEOUT) must be positive"},
+		},
+		{name: "shutdown drain disabled", modify: func(c *util.Config) { c.Server.ShutdownDrain = 0 }},
+		{
+			name:    "negative shutdown drain",
+			modify:  func(c *util.Config) { c.Server.ShutdownDrain = -time.Second },
+			wantErr: []string{"server.shutdown_drain"},
+		},
+		{
+			name:    "hs512 without secret",
+			modify:  func(c *util.Config) { c.Token.JWTSecret = "" },
+			wantErr: []string{"JWT_SECRET"},
+		},
+		{
+			name:    "asymmetric without private key",
+			modify:  func(c *util.Config) { c.Token.SigningAlg = "ES256" },
+			wantErr: []string{"token.private_key"},
+		},
+		{
+			name:    "unsupported algorithm",
+			modify:  func(c *util.Config) { c.Token.SigningAlg = "none" },
+			wantErr: []string{"unsupported algorithm"},
+		},
+		{
+			name:    "key encryption key is not base64",
+			modify:  func(c *util.Config) { c.Token.KeyEncryptionKey = "not base64!" },
+			wantErr: []string{"JWT_KEY_ENCRYPTION_KEY", "invalid base64"},
+		},
+		{
+			name:    "short key encryption key",
+			modify:  func(c *util.Config) { c.Token.KeyEncryptionKey = "c2hvcnQ=" },
+			wantErr: []string{"must be 32 bytes"},
+		},
+		{
+			name:    "bad allowlist",
+			modify:  func(c *util.Config) { c.RateLimit.Allowlist = []string{"example.com"} },
+			wantErr: []string{"rate_limit.allowlist"},
+		},
+		{
+			name: "duplicate policy",
+			modify: func(c *util.Config) {
+				policy := util.RateLimitPolicy{
+					Name:       "p",
+					Operations: []string{"RefreshTokens"},
+					Key:        models.RateLimitKeyIP,
+					Limit:      1,
+					Interval:   time.Minute,
+				}
+				c.RateLimit.Policies = []util.RateLimitPolicy{policy, policy}
+			},
+			wantErr: []string{`rate_limit.policies[1].name "p" is not unique`},
+		},
+		{
+			name: "invalid policy",
+			modify: func(c *util.Config) {
+				c.RateLimit.Policies = []util.RateLimitPolicy{{Key: "cookie"}}
+			},
+			wantErr: []string{
+				"policies[0].name is required",
+				"policies[0].operations must not be empty",
+				"policies[0].key must be one of",
+				"policies[0].limit must be positive",
+				"policies[0].interval must be positive",
+			},
+		},
+		{
+			name: "user policy on refresh",
+			modify: func(c *util.Config) {
+				c.RateLimit.Policies = []util.RateLimitPolicy{{
+					Name:       "refresh",
+					Operations: []string{models.OperationRefreshTokens},
+					Key:        models.RateLimitKeyUser,
+					Limit:      1,
+					Interval:   time.Minute,
+				}}
+			},
+			wantErr: []string{`rate_limit.policies[0].key "user" never applies to RefreshTokens`},
+		},
+		{
+			name:    "bad trusted proxies",
+			modify:  func(c *util.Config) { c.Server.TrustedProxies = []string{"proxy"} },
+			wantErr: []string{"server.trusted_proxies"},
+		},
+		{
+			name:    "revoke scope",
+			modify:  func(c *util.Config) { c.Session.ReuseRevokeScope = "all" },
+			wantErr: []string{"session.reuse_revoke_scope"},
+		},
+		{
+			name:    "webhook url without secret",
+			modify:  func(c *util.Config) { c.Webhook.URL = "http://localhost:9090" },
+			wantErr: []string{"webhook.secret"},
+		},
+		{
+			name: "relative webhook url",
+			modify: func(c *util.Config) {
+				c.Webhook.URL = "/hook"
+				c.Webhook.Secret = "secret"
+			},
+			wantErr: []string{"webhook.url"},
+		},
+		{
+			name:    "backoff max below base",
+			modify:  func(c *util.Config) { c.Webhook.BackoffMax = c.Webhook.BackoffBase - time.Second },
+			wantErr: []string{"webhook.backoff_max"},
+		},
+		{
+			name:    "bad webhook allowed networks",
+			modify:  func(c *util.Config) { c.Webhook.AllowedNetworks = []string{"internal"} },
+			wantErr: []string{"webhook.allowed_networks"},
+		},
+		{
+			name:    "sample ratio",
+			modify:  func(c *util.Config) { c.Tracing.SampleRatio = 1.5 },
+			wantErr: []string{"tracing.sample_ratio"},
+		},
+		{
+			name: "all errors at once",
+			modify: func(c *util.Config) {
+				c.DB.DSN = ""
+				c.Redis.Addr = ""
+				c.APIKey.Key = ""
+			},
+			wantErr: []string{"database.dsn", "redis.address", "api_key.key"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigValidateDB(t *testing.T) {
+	cfg := util.DefaultConfig()
+	cfg.DB.DSN = "postgres://localhost/db"
+	if err := cfg.ValidateDB(); err != nil {
+		t.Errorf("ValidateDB() with only dsn error = %v, want nil", err)
+	}
+
+	cfg.DB.DSN = ""
+	if err := cfg.ValidateDB(); err == nil || !strings.Contains(err.Error(), "database.dsn") {
+		t.Errorf("ValidateDB() without dsn error = %v, want database.dsn", err)
+	}
+}
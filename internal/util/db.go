@@ -4,9 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
@@ -14,38 +13,17 @@ import (
 )
 
 type DBConfig struct {
-	DSN string
-}
-
-func NewDBConfig() *DBConfig {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		log.Fatal("DATABASE_URL is not set")
-	}
-
-	return &DBConfig{
-		DSN: dsn,
-	}
+	DSN string `yaml:"dsn" env:"DATABASE_URL" secret:"url"`
+	// AutoMigrate накатывать миграции при старте serve. Если выключено, serve со старой схемой не стартует
+	AutoMigrate bool `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
 }
 
 type RedisConfig struct {
-	Addr string
-}
-
-func NewRedisConfig() *RedisConfig {
-	addr := os.Getenv("REDIS_ADDR")
-	if addr == "" {
-		log.Fatal("REDIS_ADDR is not set")
-	}
-
-	return &RedisConfig{
-		Addr: addr,
-	}
+	Addr string `yaml:"address" env:"REDIS_ADDR"`
 }
 
-func NewDBConnection(ctx context.Context, logger *zap.SugaredLogger) (*sql.DB, func(), error) {
-	dbConfig := NewDBConfig()
-	db, err := sql.Open("postgres", dbConfig.DSN)
+func NewDBConnection(ctx context.Context, logger *zap.SugaredLogger, cfg *DBConfig) (*sql.DB, func(), error) {
+	db, err := sql.Open("postgres", cfg.DSN)
 	if err != nil {
 		return nil, nil, fmt.Errorf("sql open: %w", err)
 	}
@@ -67,12 +45,23 @@ func NewDBConnection(ctx context.Context, logger *zap.SugaredLogger) (*sql.DB, f
 	return db, cleanup, nil
 }
 
-func NewRedisClient(logger *zap.SugaredLogger, cfg *RedisConfig) (*redis.Client, func(), error) {
+func NewRedisClient(ctx context.Context, logger *zap.SugaredLogger, cfg *RedisConfig) (*redis.Client, func(), error) {
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     cfg.Addr,
 		Password: "",
 		DB:       0,
 	})
+	// Спан на каждую команду Redis
+	if err := redisotel.InstrumentTracing(redisClient); err != nil {
+		return nil, nil, fmt.Errorf("instrument redis tracing: %w", err)
+	}
+
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		if closeErr := redisClient.Close(); closeErr != nil {
+			logger.Errorf("Failed to close Redis connection: %v", closeErr)
+		}
+		return nil, nil, fmt.Errorf("redis ping: %w", err)
+	}
 
 	logger.Info("Successfully connected to Redis!")
 
@@ -7,9 +7,9 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-func NewZapLogger() *zap.SugaredLogger {
+// NewZapLogger логгер с уровнем level: уровень можно менять на лету, не пересоздавая логгер
+func NewZapLogger(level zap.AtomicLevel) *zap.SugaredLogger {
 	stdout := zapcore.AddSync(os.Stdout)
-	level := zap.NewAtomicLevelAt(zap.DebugLevel)
 
 	developmentCfg := zap.NewDevelopmentEncoderConfig()
 	developmentCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
@@ -27,3 +27,12 @@ func NewZapLogger() *zap.SugaredLogger {
 
 	return logger.Sugar()
 }
+
+// NewLogLevel уровень из конфигурации. Значение уже проверено в Config.Validate
+func NewLogLevel(cfg *LogConfig) zap.AtomicLevel {
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		level = zapcore.DebugLevel
+	}
+	return zap.NewAtomicLevelAt(level)
+}
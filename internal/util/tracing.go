@@ -0,0 +1,74 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.uber.org/zap"
+)
+
+const otlpTracesPath = "/v1/traces"
+
+type TracingConfig struct {
+	// Endpoint OTLP/HTTP коллектора. Пусто - спаны не экспортируются
+	Endpoint    string `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	// SampleRatio доля трейсов, которые начинаются в сервисе. Входящий traceparent решает сам
+	SampleRatio float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG"`
+}
+
+// NewTracerProvider настраивает глобальные TracerProvider и W3C-пропагатор.
+// Пропагатор ставится всегда: traceparent входящего запроса доходит до webhook'ов, даже если
+// этот сервис спаны не экспортирует. Возвращаемая функция досылает буферизованные спаны.
+func NewTracerProvider(ctx context.Context, cfg *TracingConfig, logger *zap.SugaredLogger) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.Endpoint == "" {
+		logger.Info("Tracing export is disabled: OTEL_EXPORTER_OTLP_ENDPOINT is not set.")
+		return func() {}, nil
+	}
+
+	// Endpoint может прийти из файла конфигурации, поэтому передается явно, с тем же смыслом,
+	// что и OTEL_EXPORTER_OTLP_ENDPOINT: базовый URL, к которому добавляется путь сигнала.
+	// Заголовки и TLS exporter по-прежнему читает из стандартных OTEL_EXPORTER_OTLP_* сам
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(strings.TrimSuffix(cfg.Endpoint, "/")+otlpTracesPath))
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	logger.Infow("Tracing enabled", "endpoint", cfg.Endpoint, "service", cfg.ServiceName)
+
+	cleanup := func() {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			logger.Errorf("Failed to shutdown tracer provider: %v", err)
+		} else {
+			logger.Info("Tracer provider shut down successfully.")
+		}
+	}
+	return cleanup, nil
+}
@@ -0,0 +1,100 @@
+package util
+
+import "strings"
+
+const (
+	DeviceTypeDesktop = "desktop"
+	DeviceTypeMobile  = "mobile"
+	DeviceTypeTablet  = "tablet"
+	DeviceTypeBot     = "bot"
+	DeviceTypeOther   = "other"
+
+	unknownUserAgentPart = "Unknown"
+)
+
+type UserAgentInfo struct {
+	Browser    string
+	OS         string
+	DeviceType string
+}
+
+type uaRule struct {
+	token string
+	name  string
+}
+
+// Порядок важен: Edge и Opera содержат "Chrome", Chrome содержит "Safari"
+//
+//nolint:gochecknoglobals // read-only lookup tables
+var (
+	browserRules = []uaRule{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"YaBrowser/", "Yandex Browser"},
+		{"SamsungBrowser/", "Samsung Internet"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"PostmanRuntime/", "Postman"},
+		{"curl/", "curl"},
+		{"okhttp/", "OkHttp"},
+		{"Go-http-client/", "Go HTTP client"},
+	}
+	osRules = []uaRule{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+	botTokens = []string{"bot", "crawler", "spider"}
+)
+
+// ParseUserAgent грубо разбирает User-Agent для отображения пользователю ("Chrome на Windows").
+// Не предназначен для принятия решений о безопасности.
+func ParseUserAgent(ua string) UserAgentInfo {
+	info := UserAgentInfo{
+		Browser:    matchUARule(ua, browserRules),
+		OS:         matchUARule(ua, osRules),
+		DeviceType: DeviceTypeOther,
+	}
+
+	lower := strings.ToLower(ua)
+	switch {
+	case containsAny(lower, botTokens):
+		info.DeviceType = DeviceTypeBot
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet"):
+		info.DeviceType = DeviceTypeTablet
+	case strings.Contains(ua, "Mobile") || strings.Contains(ua, "iPhone"):
+		info.DeviceType = DeviceTypeMobile
+	case info.OS == "Android":
+		// Android без "Mobile" - планшет
+		info.DeviceType = DeviceTypeTablet
+	case info.OS != unknownUserAgentPart:
+		info.DeviceType = DeviceTypeDesktop
+	}
+
+	return info
+}
+
+func matchUARule(ua string, rules []uaRule) string {
+	for _, r := range rules {
+		if strings.Contains(ua, r.token) {
+			return r.name
+		}
+	}
+	return unknownUserAgentPart
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,20 @@
 package main
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -12,9 +22,225 @@ const (
 	defaultWebhookReadTimeout  = 5 * time.Second
 	defaultWebhookWriteTimeout = 10 * time.Second
 	defaultWebhookIdleTimeout  = 120 * time.Second
+
+	// Допустимое расхождение метки времени подписи с часами получателя
+	signatureTolerance = 5 * time.Minute
+	// Сколько помнить обработанные event ID. Должно быть больше, чем отправитель повторяет доставку
+	dedupeTTL = 24 * time.Hour
+
+	maxWebhookBodySize = 1 << 20
+
+	supportedEventVersion = 1
+
+	cloudEventsContentType = "application/cloudevents+json"
+	cloudEventsSpecVersion = "1.0"
+
+	signatureHeader = "X-Webhook-Signature"
 )
 
+// signedCEHeaders атрибуты binary-режима, которые отправитель включает в подпись, в порядке подписи
+//
+//nolint:gochecknoglobals // неизменяемый список
+var signedCEHeaders = []string{
+	"ce-specversion", "ce-id", "ce-source", "ce-type", "ce-time", "ce-subject", "ce-schemaversion",
+}
+
+// eventEnvelope событие в формате json: data разбирается по type и version
+type eventEnvelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// cloudEvent событие CloudEvents 1.0 в structured-режиме
+type cloudEvent struct {
+	SpecVersion   string          `json:"specversion"`
+	ID            string          `json:"id"`
+	Source        string          `json:"source"`
+	Type          string          `json:"type"`
+	Time          time.Time       `json:"time"`
+	Subject       string          `json:"subject"`
+	SchemaVersion int             `json:"schemaversion"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// receivedEvent событие, приведенное к общему виду независимо от формата доставки
+type receivedEvent struct {
+	Mode       string
+	ID         string
+	Type       string
+	Version    int
+	OccurredAt time.Time
+	Subject    string
+	Data       json.RawMessage
+}
+
+// decodeEvent определяет формат доставки:
+// structured CloudEvents - по Content-Type, binary - по заголовку ce-specversion,
+// иначе - собственный конверт сервиса
+func decodeEvent(header http.Header, body []byte) (receivedEvent, error) {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	switch {
+	case mediaType == cloudEventsContentType:
+		var ce cloudEvent
+		if err := json.Unmarshal(body, &ce); err != nil {
+			return receivedEvent{}, errors.New("error parsing CloudEvent")
+		}
+		if ce.SpecVersion != cloudEventsSpecVersion {
+			return receivedEvent{}, fmt.Errorf("unsupported CloudEvents specversion %q", ce.SpecVersion)
+		}
+		return validateEvent(receivedEvent{
+			Mode:       "cloudevents structured",
+			ID:         ce.ID,
+			Type:       ce.Type,
+			Version:    ce.SchemaVersion,
+			OccurredAt: ce.Time,
+			Subject:    ce.Subject,
+			Data:       ce.Data,
+		})
+	case header.Get("ce-specversion") != "":
+		if v := header.Get("ce-specversion"); v != cloudEventsSpecVersion {
+			return receivedEvent{}, fmt.Errorf("unsupported CloudEvents specversion %q", v)
+		}
+		occurredAt, err := time.Parse(time.RFC3339Nano, header.Get("ce-time"))
+		if err != nil {
+			return receivedEvent{}, errors.New("invalid ce-time header")
+		}
+		version, err := strconv.Atoi(header.Get("ce-schemaversion"))
+		if err != nil {
+			return receivedEvent{}, errors.New("invalid ce-schemaversion header")
+		}
+		return validateEvent(receivedEvent{
+			Mode:       "cloudevents binary",
+			ID:         header.Get("ce-id"),
+			Type:       header.Get("ce-type"),
+			Version:    version,
+			OccurredAt: occurredAt,
+			Subject:    header.Get("ce-subject"),
+			Data:       body,
+		})
+	default:
+		var envelope eventEnvelope
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return receivedEvent{}, errors.New("error parsing JSON")
+		}
+		return validateEvent(receivedEvent{
+			Mode:       "json",
+			ID:         envelope.ID,
+			Type:       envelope.Type,
+			Version:    envelope.Version,
+			OccurredAt: envelope.OccurredAt,
+			Data:       envelope.Data,
+		})
+	}
+}
+
+func validateEvent(event receivedEvent) (receivedEvent, error) {
+	if event.ID == "" || event.Type == "" {
+		return receivedEvent{}, errors.New("missing event id or type")
+	}
+	if !json.Valid(event.Data) {
+		return receivedEvent{}, errors.New("event data is not valid JSON")
+	}
+	return event, nil
+}
+
+// seenEvents event ID уже обработанных доставок: отправитель доставляет at-least-once,
+// поэтому одно событие может прийти несколько раз
+type seenEvents struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// markSeen возвращает false, если событие уже обрабатывалось
+func (s *seenEvents) markSeen(eventID string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, at := range s.seen {
+		if now.Sub(at) > dedupeTTL {
+			delete(s.seen, id)
+		}
+	}
+	if _, ok := s.seen[eventID]; ok {
+		return false
+	}
+	s.seen[eventID] = now
+	return true
+}
+
+// signedAttributes канонизирует ce-* заголовки так же, как отправитель: "<name>:<value>\n"
+// для каждого атрибута. Без ce-specversion (json и structured-режим) строка пустая.
+func signedAttributes(header http.Header) string {
+	if header.Get("ce-specversion") == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, name := range signedCEHeaders {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(header.Get(name))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// verifySignature проверяет заголовок "t=<unix>,v1=<hex>[,v1=<hex>...]" над "<t>.<attrs><body>",
+// где attrs - подписанные ce-* атрибуты (см. signedAttributes).
+// Несколько v1 допускается на время смены секрета: достаточно совпадения одной.
+func verifySignature(secret []byte, header, attrs string, body []byte, now time.Time) bool {
+	var (
+		timestamp  string
+		signatures []string
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return false
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > signatureTolerance || age < -signatureTolerance {
+		log.Printf("Rejecting webhook: stale timestamp %s", time.Unix(unix, 0))
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(attrs))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, sig := range signatures {
+		got, err := hex.DecodeString(sig)
+		if err == nil && hmac.Equal(got, expected) {
+			return true
+		}
+	}
+	return false
+}
+
 func main() {
+	secret := []byte(os.Getenv("WEBHOOK_SECRET"))
+	if len(secret) == 0 {
+		log.Fatal("WEBHOOK_SECRET is not set")
+	}
+	events := &seenEvents{seen: map[string]time.Time{}}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -22,24 +248,45 @@ func main() {
 			return
 		}
 
-		body, err := io.ReadAll(r.Body)
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
 		if err != nil {
 			http.Error(w, "Error reading request body", http.StatusInternalServerError)
 			return
 		}
 		defer r.Body.Close()
 
-		var data map[string]interface{}
-		if err := json.Unmarshal(body, &data); err != nil {
-			http.Error(w, "Error parsing JSON", http.StatusBadRequest)
+		// Подпись проверяется по сырому телу и ce-* заголовкам, до разбора JSON
+		attrs := signedAttributes(r.Header)
+		if !verifySignature(secret, r.Header.Get(signatureHeader), attrs, body, time.Now()) {
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		event, err := decodeEvent(r.Header, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		eventID := event.ID
+		if !events.markSeen(eventID, time.Now()) {
+			// 2xx, чтобы отправитель прекратил повторы
+			log.Printf("Duplicate webhook %s, skipping", eventID)
+			w.WriteHeader(http.StatusOK)
 			return
 		}
 
-		log.Println("Received webhook:")
-		log.Printf("  User ID: %.0f", data["user_id"])
-		log.Printf("  Old IP: %s", data["old_ip"])
-		log.Printf("  New IP: %s", data["new_ip"])
-		log.Printf("  User Agent: %s", data["user_agent"])
+		// Версия схемы меняется только при несовместимых изменениях data:
+		// незнакомую версию лучше не разбирать, чем разобрать неправильно
+		if event.Version != supportedEventVersion {
+			log.Printf("Received webhook %s (%s v%d): unsupported version, skipping", eventID, event.Type, event.Version)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		log.Printf("Received webhook %s (%s): %s at %s", eventID, event.Mode, event.Type, event.OccurredAt.Format(time.RFC3339))
+		if event.Subject != "" {
+			log.Printf("  Subject: %s", event.Subject)
+		}
+		log.Printf("  Data: %s", event.Data)
 
 		w.WriteHeader(http.StatusOK)
 		if _, err := w.Write([]byte("Webhook received!")); err != nil {
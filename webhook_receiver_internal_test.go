@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(secret []byte, ts time.Time, attrs string, body []byte) string {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "." + attrs))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func sigHeader(ts time.Time, signatures ...string) string {
+	header := "t=" + strconv.FormatInt(ts.Unix(), 10)
+	for _, sig := range signatures {
+		header += ",v1=" + sig
+	}
+	return header
+}
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"type":"logout"}`)
+	attrs := "ce-id:1\nce-source:/auth-service\n"
+	valid := sign(secret, now, attrs, body)
+	stale := now.Add(-signatureTolerance - time.Second)
+	future := now.Add(signatureTolerance + time.Second)
+
+	tests := []struct {
+		name   string
+		header string
+		attrs  string
+		body   []byte
+		want   bool
+	}{
+		{name: "valid", header: sigHeader(now, valid), attrs: attrs, body: body, want: true},
+		{
+			name:   "valid without attributes",
+			header: sigHeader(now, sign(secret, now, "", body)),
+			body:   body,
+			want:   true,
+		},
+		{
+			name:   "spaces around parts",
+			header: "t=" + strconv.FormatInt(now.Unix(), 10) + ", v1=" + valid,
+			attrs:  attrs,
+			body:   body,
+			want:   true,
+		},
+		{
+			name:   "one of several signatures matches",
+			header: sigHeader(now, sign([]byte("old"), now, attrs, body), valid),
+			attrs:  attrs,
+			body:   body,
+			want:   true,
+		},
+		{
+			name:   "wrong secret",
+			header: sigHeader(now, sign([]byte("other"), now, attrs, body)),
+			attrs:  attrs,
+			body:   body,
+		},
+		{name: "modified body", header: sigHeader(now, valid), attrs: attrs, body: []byte(`{"type":"x"}`)},
+		{name: "modified attributes", header: sigHeader(now, valid), attrs: "ce-id:2\n", body: body},
+		{name: "timestamp does not match", header: sigHeader(now.Add(time.Second), valid), attrs: attrs, body: body},
+		{name: "stale timestamp", header: sigHeader(stale, sign(secret, stale, attrs, body)), attrs: attrs, body: body},
+		{
+			name:   "future timestamp",
+			header: sigHeader(future, sign(secret, future, attrs, body)),
+			attrs:  attrs,
+			body:   body,
+		},
+		{name: "no timestamp", header: "v1=" + valid, attrs: attrs, body: body},
+		{name: "no signature", header: sigHeader(now), attrs: attrs, body: body},
+		{name: "signature is not hex", header: sigHeader(now, "zz"), attrs: attrs, body: body},
+		{name: "empty header", attrs: attrs, body: body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifySignature(secret, tt.header, tt.attrs, tt.body, now); got != tt.want {
+				t.Errorf("verifySignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignedAttributes(t *testing.T) {
+	structured := http.Header{}
+	if got := signedAttributes(structured); got != "" {
+		t.Errorf("signedAttributes() without ce-specversion = %q, want empty", got)
+	}
+
+	binary := http.Header{}
+	binary.Set("ce-specversion", "1.0")
+	binary.Set("ce-id", "42")
+	want := ""
+	for _, name := range signedCEHeaders {
+		want += name + ":" + binary.Get(name) + "\n"
+	}
+	if got := signedAttributes(binary); got != want {
+		t.Errorf("signedAttributes() = %q, want %q", got, want)
+	}
+}